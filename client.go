@@ -0,0 +1,89 @@
+package go2flow
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+
+	"github.com/kristiehoward/go2flow/handlers"
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// ClientName returns the name of the client module generated for the routes and
+// services of a .go file, e.g. products_client.js for products.go
+func ClientName(file string) string {
+	return strings.TrimSuffix(file, ".go") + "_client.js"
+}
+
+// generateClient generates the client module for the routes and services of
+// the file, which imports the request and response types from the modules
+// generated for the files defining them
+func (g *generator) generateClient(file string, astFile *ast.File, routes []handlers.Route, services []handlers.Service) {
+	g.types.BeginFile(astFile)
+	h := handlers.NewHandler(g.types)
+	h.HandleClient(routes, services)
+
+	f := h.File
+	f.Name = ClientName(file)
+	f.Source = packageSource(file, astFile)
+	modules := map[string][]string{}
+	for _, typeName := range g.localTypes(routes, services) {
+		module := relativeModule(f.Name, OutputName(g.types.Fset.Position(g.types.Pkg.Types[typeName].Pos()).Filename))
+		modules[module] = append(modules[module], typeName)
+	}
+	for _, module := range sortedKeys(modules) {
+		imp := &model.Import{Module: module}
+		for _, typeName := range modules[module] {
+			imp.Names = append(imp.Names, &model.ImportName{Name: typeName})
+		}
+		f.Imports = append(f.Imports, imp)
+	}
+	f.Imports = append(f.Imports, g.imports(f.Name, g.types.ImportedTypes())...)
+	linkImports(f)
+	g.schema.Files = append(g.schema.Files, f)
+}
+
+// localTypes returns the sorted names of the package's types referenced by the
+// request and response types of the routes and services
+func (g *generator) localTypes(routes []handlers.Route, services []handlers.Service) []string {
+	seen := map[string]bool{}
+	var names []string
+	visit := func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			// A type of another package
+			return false
+		case *ast.Ident:
+			if _, ok := g.types.Pkg.Types[n.Name]; ok && n.IsExported() && !seen[n.Name] {
+				seen[n.Name] = true
+				names = append(names, n.Name)
+			}
+		}
+		return true
+	}
+	for _, r := range routes {
+		if r.Request != nil {
+			ast.Inspect(r.Request, visit)
+		}
+		if r.Response != nil {
+			ast.Inspect(r.Response, visit)
+		}
+	}
+	for _, s := range services {
+		for _, rpc := range s.Methods {
+			ast.Inspect(rpc.Request, visit)
+			ast.Inspect(rpc.Response, visit)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kristiehoward/go2flow"
+	"github.com/kristiehoward/go2flow/diff"
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/urfave/cli"
+)
+
+// Exit codes of the diff command by the highest severity of the changes.
+// Errors exit with 1.
+var diffExitCodes = map[diff.Severity]int{
+	diff.Safe:                0,
+	diff.PotentiallyBreaking: 2,
+	diff.Breaking:            3,
+}
+
+var diffCommand = cli.Command{
+	Name:      "diff",
+	Usage:     "report the changes to the generated types between two revisions, and whether they break the code using them",
+	ArgsUsage: "<old> <new>",
+	Description: `Each revision is a directory, or a git ref of the directory given with --dir
+   (the current directory by default). The exit code is 0 when the changes are
+   safe, 2 when they are potentially breaking and 3 when they are breaking.`,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "format",
+			Value: "text",
+			Usage: "format of the report, text or json",
+		},
+	}, flags...),
+	Action: runDiff,
+}
+
+func runDiff(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("expected the <old> and <new> revisions to compare")
+	}
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+	cfg, err := config(c)
+	if err != nil {
+		return err
+	}
+	dir := c.String("dir")
+	if dir == "" {
+		dir = "."
+	}
+
+	var models [2]*model.Schema
+	for i, revision := range c.Args()[:2] {
+		if models[i], err = analyzeRevision(cfg, revision, dir); err != nil {
+			return err
+		}
+	}
+
+	changes := diff.Compare(models[0], models[1])
+	if format == "json" {
+		err = diff.WriteJSON(os.Stdout, changes)
+	} else {
+		err = diff.WriteText(os.Stdout, changes)
+	}
+	if err != nil {
+		return err
+	}
+	if code := diffExitCodes[diff.MaxSeverity(changes)]; code != 0 {
+		return cli.NewExitError("", code)
+	}
+	return nil
+}
+
+// analyzeRevision returns the model of the types of a revision, which is either
+// a directory or a git ref of dir
+func analyzeRevision(cfg go2flow.Config, revision, dir string) (*model.Schema, error) {
+	if info, err := os.Stat(revision); err == nil && info.IsDir() {
+		cfg.Patterns = []string{revision}
+	} else {
+		tmp, err := ioutil.TempDir("", "go2flow-diff")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmp)
+		checkout, err := checkoutRef(revision, dir, tmp)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Patterns = []string{checkout}
+	}
+
+	m, diagnostics, err := go2flow.Analyze(context.Background(), cfg)
+	for _, d := range diagnostics {
+		fmt.Fprintln(os.Stderr, d)
+	}
+	return m, err
+}
+
+// checkoutRef extracts dir as of the git ref into tmp, and returns the path of
+// the extracted dir
+func checkoutRef(ref, dir, tmp string) (string, error) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	top = strings.TrimSpace(top)
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	// The top level is reported with symlinks resolved
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(top, abs)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"archive", "--format=tar", ref}
+	if rel != "." {
+		args = append(args, "--", filepath.ToSlash(rel))
+	}
+	archive, err := git(top, args...)
+	if err != nil {
+		return "", err
+	}
+
+	r := tar.NewReader(strings.NewReader(archive))
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if hdr.Typeflag != tar.TypeReg || strings.Contains(hdr.Name, "..") {
+			continue
+		}
+		name := filepath.Join(tmp, filepath.FromSlash(hdr.Name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return "", err
+		}
+		var content bytes.Buffer
+		if _, err := io.Copy(&content, r); err != nil {
+			return "", err
+		}
+		if err := ioutil.WriteFile(name, content.Bytes(), 0644); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(tmp, rel), nil
+}
+
+// git runs a git command in dir and returns its output
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kristiehoward/go2flow"
+	"github.com/kristiehoward/go2flow/flow"
+	"github.com/kristiehoward/go2flow/handlers"
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/kristiehoward/go2flow/templates"
+	"github.com/kristiehoward/go2flow/typeutils"
+	"github.com/urfave/cli"
+)
+
+const (
+	appName  = "Go2Flow"
+	appUsage = `Convert Golang types to Flow types`
+)
+
+var (
+	flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Usage: ".go file to consume",
+		},
+		cli.StringFlag{
+			Name:  "dir, d",
+			Usage: "directory containing .go file to consume",
+		},
+		cli.StringFlag{
+			Name:  "lang, l",
+			Value: go2flow.LanguageFlow,
+			Usage: "target language of the generated definitions: " + strings.Join(go2flow.Languages(), ", "),
+		},
+		cli.StringFlag{
+			Name:  "pack, p",
+			Usage: "comma separated mapping packs for common third-party types: " + strings.Join(typeutils.PackNames(), ", "),
+		},
+		cli.StringFlag{
+			Name:  "tags, t",
+			Value: "json",
+			Usage: "comma separated struct tag keys, in order of precedence, that decide property names, e.g. yaml,json",
+		},
+		cli.BoolFlag{
+			Name:  "json-v2",
+			Usage: "follow the semantics of encoding/json/v2 for json tags",
+		},
+		cli.BoolFlag{
+			Name:  "opaque",
+			Usage: "emit types defined from a string, number or boolean as Flow opaque types",
+		},
+		cli.BoolFlag{
+			Name:  "enum-objects",
+			Usage: "emit the values and labels of enum types at runtime",
+		},
+		cli.BoolFlag{
+			Name:  "export-consts",
+			Usage: "emit the exported constants that don't belong to an enum as JS constants",
+		},
+		cli.StringFlag{
+			Name:  "rpc-prefix",
+			Value: handlers.DefaultRPCPrefix,
+			Usage: "path prefix of the methods of services, / for Connect",
+		},
+		cli.IntFlag{
+			Name:  "max-tuple-length",
+			Value: typeutils.DefaultMaxTupleLength,
+			Usage: "longest fixed-size array translated to a tuple rather than Array<T>",
+		},
+		cli.StringSliceFlag{
+			Name:  "import-module",
+			Usage: "Flow module defining the types of an imported Go package, as `ImportPath=module`",
+		},
+		cli.BoolFlag{
+			Name:  "generate-imports",
+			Usage: "generate the types of the other imported packages from vendor/, the GOPATH or the module cache",
+		},
+		cli.StringFlag{
+			Name:  "imports-dir",
+			Value: go2flow.DefaultImportsDir,
+			Usage: "directory for the types generated for imported packages",
+		},
+		cli.BoolFlag{
+			Name:  "strict",
+			Usage: "exit non-zero when any construct could not be translated, ignoring warnings",
+		},
+		cli.StringSliceFlag{
+			Name:  "map, m",
+			Usage: "additional `GoType=FlowType` mapping, e.g. time.Duration=number",
+		},
+	}
+)
+
+func run(c *cli.Context) error {
+	file := c.String("file")
+	dir := c.String("dir")
+
+	// TODO Maxime 11/5/2017
+	// Check if the file passed in the CLI has the .go extension
+	if file == "" && dir == "" {
+		fmt.Println("Please specify a .go file to consume")
+		return nil
+	}
+
+	cfg, err := config(c)
+	if err != nil {
+		return err
+	}
+	// Handle directory
+	if dir != "" {
+		cfg.Patterns = []string{dir}
+	} else {
+		// Handle file
+		cfg.Patterns = []string{file}
+	}
+
+	output := c.String("emit")
+	if output != "code" && output != "ir" {
+		return fmt.Errorf("unsupported output %q, expected code or ir", output)
+	}
+
+	schema, diagnostics, err := go2flow.Analyze(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	for _, d := range diagnostics {
+		fmt.Fprintln(os.Stderr, d)
+	}
+	if failed := go2flow.Errors(diagnostics); c.Bool("strict") && len(failed) > 0 {
+		return fmt.Errorf("%d construct(s) could not be translated", len(failed))
+	}
+
+	if output == "ir" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(schema)
+	}
+	outputs, err := emit(c, schema, cfg)
+	if err != nil {
+		return err
+	}
+	return writeOutputs(c, outputs)
+}
+
+// emit prints the modules of the schema through the --template file if given,
+// or else with the emitter of the config's language
+func emit(c *cli.Context, schema *model.Schema, cfg go2flow.Config) (map[string][]byte, error) {
+	if path := c.String("template"); path != "" {
+		e, err := templates.ParseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		e.Ext = c.String("template-ext")
+		return go2flow.Emit(schema, e)
+	}
+	return go2flow.Render(schema, cfg)
+}
+
+// templateFlags render the modules through a template rather than an emitter
+var templateFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "template",
+		Usage: "text/template `file` to render each module through instead of the target language",
+	},
+	cli.StringFlag{
+		Name:  "template-ext",
+		Usage: "`extension` of the files the --template writes, e.g. .md, instead of .js",
+	},
+}
+
+// formatFlags are the layout options of the Flow modules, named after the
+// Prettier options that produce the same layout
+var formatFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "tab-width",
+		Value: 2,
+		Usage: "number of spaces per indentation level",
+	},
+	cli.BoolFlag{
+		Name:  "use-tabs",
+		Usage: "indent with tabs rather than spaces",
+	},
+	cli.BoolFlag{
+		Name:  "single-quote",
+		Usage: "quote strings with single rather than double quotes",
+	},
+	cli.StringFlag{
+		Name:  "trailing-comma",
+		Value: flow.TrailingCommaAll,
+		Usage: "where trailing commas are printed in broken lists: all, es5 or none",
+	},
+	cli.BoolFlag{
+		Name:  "no-semi",
+		Usage: "leave out the semicolons at the end of statements",
+	},
+	cli.IntFlag{
+		Name:  "print-width",
+		Value: 80,
+		Usage: "line length that lists and unions are wrapped at",
+	},
+}
+
+// prologueFlags decide the comment at the top of the Flow modules
+var prologueFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "pragma",
+		Usage: "Flow pragma at the top of the modules: " + flow.PragmaFlow + " or \"" + flow.PragmaFlowStrict + "\"",
+	},
+	cli.BoolFlag{
+		Name:  "generated",
+		Usage: "add an @generated banner naming the Go source of each module and the command regenerating it",
+	},
+	cli.StringFlag{
+		Name:  "regenerate",
+		Usage: "command regenerating the modules, given in the @generated banner (default: the current command)",
+	},
+	cli.StringSliceFlag{
+		Name:  "eslint-disable",
+		Usage: "comma separated rules of an eslint-disable comment at the top of the modules, empty for all rules; repeat for several comments",
+	},
+}
+
+// prologue returns the prologue of the prologue flags
+func prologue(c *cli.Context) flow.Prologue {
+	command := c.String("regenerate")
+	if command == "" {
+		command = commandLine(os.Args)
+	}
+	return flow.Prologue{
+		Pragma:        c.String("pragma"),
+		Generated:     c.Bool("generated"),
+		Command:       command,
+		ESLintDisable: c.StringSlice("eslint-disable"),
+	}
+}
+
+// commandLine returns the command line of the arguments as typed in a shell,
+// with the program named go2flow
+func commandLine(args []string) string {
+	words := []string{"go2flow"}
+	for _, arg := range args[1:] {
+		if arg == "" || strings.ContainsAny(arg, " \t'\"\\$`*?;&|<>()") {
+			arg = "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// format returns the layout of the format flags
+func format(c *cli.Context) flow.Format {
+	return flow.Format{
+		TabWidth:      c.Int("tab-width"),
+		UseTabs:       c.Bool("use-tabs"),
+		SingleQuote:   c.Bool("single-quote"),
+		TrailingComma: c.String("trailing-comma"),
+		NoSemi:        c.Bool("no-semi"),
+		PrintWidth:    c.Int("print-width"),
+	}
+}
+
+// writeFlag writes the modules to their files rather than stdout
+var writeFlag = cli.BoolFlag{
+	Name:  "write, w",
+	Usage: "write each module to its file, keeping the regions between " + go2flow.ManualBegin + " and " + go2flow.ManualEnd + " lines, instead of stdout",
+}
+
+// checkFlag compares the modules with their files rather than printing them
+var checkFlag = cli.BoolFlag{
+	Name:  "check",
+	Usage: "fail if a module's file is missing or out of date, e.g. in CI, instead of printing the modules",
+}
+
+// writeOutputs writes the generated modules to their files with --write,
+// checks them against their files with --check, or else writes them to
+// stdout, ordered by name
+func writeOutputs(c *cli.Context, outputs map[string][]byte) error {
+	if c.Bool("write") {
+		return go2flow.WriteOutputs(outputs)
+	}
+	if c.Bool("check") {
+		stale, err := go2flow.StaleOutputs(outputs)
+		if err != nil {
+			return err
+		}
+		if len(stale) > 0 {
+			return fmt.Errorf("generated files are out of date, regenerate them with --write:\n  %s", strings.Join(stale, "\n  "))
+		}
+		return nil
+	}
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		os.Stdout.Write(outputs[name])
+	}
+	return nil
+}
+
+// config returns the translation options of the flags, without the files to
+// translate
+func config(c *cli.Context) (go2flow.Config, error) {
+	mappings, err := parsePairs(c.StringSlice("map"))
+	if err != nil {
+		return go2flow.Config{}, err
+	}
+	importModules, err := parsePairs(c.StringSlice("import-module"))
+	if err != nil {
+		return go2flow.Config{}, err
+	}
+
+	var packs []string
+	if c.String("pack") != "" {
+		packs = strings.Split(c.String("pack"), ",")
+	}
+
+	cfg := go2flow.Config{
+		Language:     c.String("lang"),
+		Format:       format(c),
+		Prologue:     prologue(c),
+		TypeMappings: mappings,
+		Packs:        packs,
+		TagKeys:      strings.Split(c.String("tags"), ","),
+		JSONv2:       c.Bool("json-v2"),
+		Opaque:       c.Bool("opaque"),
+		EnumObjects:  c.Bool("enum-objects"),
+
+		ExportConsts:   c.Bool("export-consts"),
+		RPCPrefix:      c.String("rpc-prefix"),
+		MaxTupleLength: c.Int("max-tuple-length"),
+
+		ImportModules:   importModules,
+		GenerateImports: c.Bool("generate-imports"),
+		ImportsDir:      c.String("imports-dir"),
+	}
+	return cfg, nil
+}
+
+// parsePairs parses `key=value` flag values such as `GoType=FlowType`
+func parsePairs(pairs []string) (map[string]string, error) {
+	m := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		s := strings.SplitN(pair, "=", 2)
+		if len(s) != 2 || s[0] == "" || s[1] == "" {
+			return nil, fmt.Errorf("invalid value %q, expected key=value", pair)
+		}
+		m[s[0]] = s[1]
+	}
+	return m, nil
+}
+
+// TODO Kristie 10/24/17
+// - Dockerize development
+// - Optionally keep the comments by the struct defs?
+// - Handle definitions not in the struct tags (talk to Maxime)
+func main() {
+	app := cli.NewApp()
+	app.Name = appName
+	app.Usage = appUsage
+	app.Version = "0.0.1"
+	app.Flags = append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "emit",
+			Value: "code",
+			Usage: "output, code in the target language, or ir for the JSON model that the render command prints",
+		},
+		writeFlag,
+		checkFlag,
+	}, append(append(append(flags, templateFlags...), formatFlags...), prologueFlags...)...)
+	app.Action = run
+	app.Commands = []cli.Command{diffCommand, renderCommand}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/kristiehoward/go2flow"
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/urfave/cli"
+)
+
+var renderCommand = cli.Command{
+	Name:      "render",
+	Usage:     "print the modules of a model saved with --emit ir",
+	ArgsUsage: "<ir.json>",
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "lang, l",
+			Value: go2flow.LanguageFlow,
+			Usage: "target language of the generated definitions: " + strings.Join(go2flow.Languages(), ", "),
+		},
+		writeFlag,
+		checkFlag,
+	}, append(append(templateFlags, formatFlags...), prologueFlags...)...),
+	Action: runRender,
+}
+
+func runRender(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected the <ir.json> file to render")
+	}
+	data, err := ioutil.ReadFile(c.Args()[0])
+	if err != nil {
+		return err
+	}
+	// Numbers are decoded as json.Number to keep the values of integer
+	// constants exact
+	var schema model.Schema
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&schema); err != nil {
+		return fmt.Errorf("%s: %v", c.Args()[0], err)
+	}
+	if schema.Version == 0 {
+		return fmt.Errorf("%s isn't a model saved with --emit ir", c.Args()[0])
+	}
+
+	outputs, err := emit(c, &schema, go2flow.Config{Language: c.String("lang"), Format: format(c), Prologue: prologue(c)})
+	if err != nil {
+		return err
+	}
+	return writeOutputs(c, outputs)
+}
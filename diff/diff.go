@@ -0,0 +1,290 @@
+// Package diff compares the models of the types generated for two revisions of
+// a package and classifies the changes by how they affect the code using them.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/kristiehoward/go2flow/flow"
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// Severity is how a change affects the code that reads or writes the types
+type Severity int
+
+const (
+	// Safe changes don't break any code, e.g. an added optional field
+	Safe Severity = iota
+	// PotentiallyBreaking changes break code that relies on the values it
+	// reads being exhaustive, e.g. an added enum value or a widened type
+	PotentiallyBreaking
+	// Breaking changes break code that reads or writes the types, e.g. a
+	// removed field, a narrowed type or a removed enum value
+	Breaking
+)
+
+var severityNames = []string{"safe", "potentially-breaking", "breaking"}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// MarshalText encodes the severity by name
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Change is a difference between the models of two revisions
+type Change struct {
+	// Type is the name of the changed type
+	Type string `json:"type"`
+	// Field identifies the changed field, see model.Field.ID
+	Field string `json:"field,omitempty"`
+	// Kind is the kind of change, e.g. field-removed
+	Kind string `json:"kind"`
+	// Severity classifies the change
+	Severity Severity `json:"severity"`
+	// Detail describes the change
+	Detail string `json:"detail"`
+}
+
+// Path returns the type and field of the change, e.g. Product.price
+func (c Change) Path() string {
+	if c.Field == "" {
+		return c.Type
+	}
+	return c.Type + "." + c.Field
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.Path(), c.Detail)
+}
+
+// Compare returns the changes to the types of the translated files from the
+// old to the new revision, ordered by type
+func Compare(old, new *model.Schema) []Change {
+	oldTypes, newTypes := types(old), types(new)
+	var changes []Change
+	for _, name := range sortedNames(oldTypes) {
+		newType, ok := newTypes[name]
+		if !ok {
+			changes = append(changes, Change{Type: name, Kind: "type-removed", Severity: Breaking, Detail: "type removed"})
+			continue
+		}
+		changes = append(changes, compareTypes(oldTypes[name], newType)...)
+	}
+	for _, name := range sortedNames(newTypes) {
+		if _, ok := oldTypes[name]; !ok {
+			changes = append(changes, Change{Type: name, Kind: "type-added", Severity: Safe, Detail: "type added"})
+		}
+	}
+	return changes
+}
+
+// types returns the types of the modules of the translated files by name,
+// leaving out those of imported packages
+func types(schema *model.Schema) map[string]*model.Type {
+	types := map[string]*model.Type{}
+	for _, f := range schema.Files {
+		if f.Package != "" {
+			continue
+		}
+		for _, t := range f.Types() {
+			types[t.Name] = t
+		}
+	}
+	return types
+}
+
+func sortedNames(types map[string]*model.Type) []string {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MaxSeverity returns the highest severity of the changes, Safe if there are
+// none
+func MaxSeverity(changes []Change) Severity {
+	max := Safe
+	for _, c := range changes {
+		if c.Severity > max {
+			max = c.Severity
+		}
+	}
+	return max
+}
+
+func compareTypes(old, new *model.Type) []Change {
+	name := old.Name
+	if old.Kind != new.Kind {
+		return []Change{{
+			Type: name, Kind: "kind-changed", Severity: Breaking,
+			Detail: fmt.Sprintf("changed from %s to %s", old.Kind, new.Kind),
+		}}
+	}
+
+	switch old.Kind {
+	case model.KindStruct:
+		return compareFields(old, new)
+	case model.KindEnum:
+		var changes []Change
+		oldValues, newValues := typeStrings(old.Literals()), typeStrings(new.Literals())
+		for _, v := range oldValues {
+			if !contains(newValues, v) {
+				changes = append(changes, Change{Type: name, Kind: "enum-value-removed", Severity: Breaking, Detail: "enum value " + v + " removed"})
+			}
+		}
+		for _, v := range newValues {
+			if !contains(oldValues, v) {
+				changes = append(changes, Change{Type: name, Kind: "enum-value-added", Severity: PotentiallyBreaking, Detail: "enum value " + v + " added"})
+			}
+		}
+		return changes
+	}
+	if c, ok := compareTypeExprs(old.Type, new.Type); ok {
+		c.Type = name
+		return []Change{c}
+	}
+	return nil
+}
+
+func compareFields(old, new *model.Type) []Change {
+	var changes []Change
+	for _, oldField := range old.Fields {
+		id := oldField.ID()
+		newField, ok := new.Field(id)
+		if !ok {
+			changes = append(changes, Change{Type: old.Name, Field: id, Kind: "field-removed", Severity: Breaking, Detail: "field removed"})
+			continue
+		}
+
+		switch {
+		case oldField.Optional && !newField.Optional:
+			changes = append(changes, Change{Type: old.Name, Field: id, Kind: "field-required", Severity: Breaking, Detail: "optional field made required"})
+		case !oldField.Optional && newField.Optional:
+			changes = append(changes, Change{Type: old.Name, Field: id, Kind: "field-optional", Severity: PotentiallyBreaking, Detail: "required field made optional"})
+		}
+		if c, ok := compareTypeExprs(fieldType(oldField), fieldType(newField)); ok {
+			c.Type, c.Field = old.Name, id
+			changes = append(changes, c)
+		}
+	}
+	for _, newField := range new.Fields {
+		id := newField.ID()
+		if _, ok := old.Field(id); ok {
+			continue
+		}
+		if newField.Optional {
+			changes = append(changes, Change{Type: old.Name, Field: id, Kind: "field-added", Severity: Safe, Detail: "optional field added"})
+		} else {
+			changes = append(changes, Change{Type: old.Name, Field: id, Kind: "field-added", Severity: PotentiallyBreaking, Detail: "required field added"})
+		}
+	}
+	return changes
+}
+
+// fieldType returns the type of a field's values, including null
+func fieldType(f *model.Field) *model.TypeExpr {
+	if f.Nullable {
+		return model.NullableOf(f.Type)
+	}
+	return f.Type
+}
+
+// compareTypeExprs classifies the change between two types, described by their
+// Flow types: a type that accepts fewer values is narrowed, one that accepts
+// more is widened
+func compareTypeExprs(old, new *model.TypeExpr) (Change, bool) {
+	oldFlow, newFlow := flow.TypeString(old), flow.TypeString(new)
+	if oldFlow == newFlow {
+		return Change{}, false
+	}
+	oldMembers, newMembers := typeStrings(unionMembers(old)), typeStrings(unionMembers(new))
+	detail := fmt.Sprintf("from %s to %s", oldFlow, newFlow)
+	switch {
+	case old.IsPrimitive(model.Unknown) || subset(newMembers, oldMembers):
+		return Change{Kind: "type-narrowed", Severity: Breaking, Detail: "type narrowed " + detail}, true
+	case new.IsPrimitive(model.Unknown) || subset(oldMembers, newMembers):
+		return Change{Kind: "type-widened", Severity: PotentiallyBreaking, Detail: "type widened " + detail}, true
+	}
+	return Change{Kind: "type-changed", Severity: Breaking, Detail: "type changed " + detail}, true
+}
+
+// unionMembers returns the members of a type's top level union, with a nullable
+// type contributing null and void as a Flow maybe type does
+func unionMembers(t *model.TypeExpr) []*model.TypeExpr {
+	switch {
+	case t == nil:
+		return nil
+	case t.Kind == model.TypeUnion:
+		var members []*model.TypeExpr
+		for _, m := range t.Members {
+			members = append(members, unionMembers(m)...)
+		}
+		return members
+	case t.Kind == model.TypeNullable:
+		return append(unionMembers(t.Elem), model.Primitive(model.Null), model.Primitive(model.Void))
+	}
+	return []*model.TypeExpr{t}
+}
+
+// typeStrings returns the Flow types of the type expressions
+func typeStrings(types []*model.TypeExpr) []string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = flow.TypeString(t)
+	}
+	return s
+}
+
+// subset reports whether every member of a is a member of b
+func subset(a, b []string) bool {
+	for _, m := range a {
+		if !contains(b, m) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText writes a line per change, preceded by its severity
+func WriteText(w io.Writer, changes []Change) error {
+	for _, c := range changes {
+		if _, err := fmt.Fprintf(w, "%-20s %s\n", c.Severity, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Report is the JSON report of the changes between two revisions
+type Report struct {
+	// Severity is the highest severity of the changes
+	Severity Severity `json:"severity"`
+	Changes  []Change `json:"changes"`
+}
+
+// WriteJSON writes the changes as a JSON Report
+func WriteJSON(w io.Writer, changes []Change) error {
+	if changes == nil {
+		changes = []Change{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Report{Severity: MaxSeverity(changes), Changes: changes})
+}
@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kristiehoward/go2flow/flow"
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	old := schema(
+		&model.Type{Name: "Product", Kind: model.KindStruct, Fields: []*model.Field{
+			{Name: "id", Type: mustParse("string")},
+			{Name: "name", Type: mustParse("string"), Optional: true},
+			{Name: "price", Type: mustParse("number | string")},
+			{Name: "owner", Type: mustParse("string"), Nullable: true},
+			{Name: "sku", Type: mustParse("string")},
+		}},
+		&model.Type{Name: "Status", Kind: model.KindEnum, Values: []*model.EnumValue{
+			{Key: "Active", Value: "active"}, {Key: "Retired", Value: "retired"},
+		}},
+		&model.Type{Name: "Tags", Kind: model.KindAlias, Type: mustParse("Array<string>")},
+		&model.Type{Name: "Legacy", Kind: model.KindAlias, Type: mustParse("string")},
+	)
+	new := schema(
+		&model.Type{Name: "Product", Kind: model.KindStruct, Fields: []*model.Field{
+			{Name: "id", Type: mustParse("string")},
+			{Name: "name", Type: mustParse("string")},
+			{Name: "price", Type: mustParse("number")},
+			{Name: "owner", Type: mustParse("string")},
+			{Name: "note", Type: mustParse("string"), Optional: true},
+			{Name: "stock", Type: mustParse("number")},
+		}},
+		&model.Type{Name: "Status", Kind: model.KindEnum, Values: []*model.EnumValue{
+			{Key: "Active", Value: "active"}, {Key: "Draft", Value: "draft"},
+		}},
+		&model.Type{Name: "Tags", Kind: model.KindAlias, Type: mustParse("?Array<string>")},
+		&model.Type{Name: "Region", Kind: model.KindAlias, Type: mustParse("string")},
+	)
+
+	changes := Compare(old, new)
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, c.Severity.String()+" "+c.Kind+" "+c.String())
+	}
+	assert.Equal(t, []string{
+		"breaking type-removed Legacy: type removed",
+		"breaking field-required Product.name: optional field made required",
+		"breaking type-narrowed Product.price: type narrowed from number | string to number",
+		"breaking type-narrowed Product.owner: type narrowed from ?string to string",
+		"breaking field-removed Product.sku: field removed",
+		"safe field-added Product.note: optional field added",
+		"potentially-breaking field-added Product.stock: required field added",
+		`breaking enum-value-removed Status: enum value "retired" removed`,
+		`potentially-breaking enum-value-added Status: enum value "draft" added`,
+		"potentially-breaking type-widened Tags: type widened from Array<string> to ?Array<string>",
+		"safe type-added Region: type added",
+	}, lines)
+	assert.Equal(t, Breaking, MaxSeverity(changes))
+	assert.Equal(t, Safe, MaxSeverity(Compare(old, old)))
+}
+
+// schema returns a schema with a translated file defining the types, and an
+// imported package's module defining an unrelated type
+func schema(types ...*model.Type) *model.Schema {
+	f := &model.File{Name: "types.js"}
+	for _, t := range types {
+		f.Decls = append(f.Decls, &model.Decl{Type: t})
+	}
+	imported := &model.File{Name: "imports/example.com/money.js", Package: "example.com/money", Decls: []*model.Decl{
+		{Type: &model.Type{Name: "Amount", Kind: model.KindAlias, Type: mustParse("string")}},
+	}}
+	return &model.Schema{Version: model.Version, Files: []*model.File{f, imported}}
+}
+
+func TestUnionMembers(t *testing.T) {
+	assert.Equal(t, []string{"number", "string"}, typeStrings(unionMembers(mustParse("number | string"))))
+	assert.Equal(t, []string{"number", "string", "null", "void"}, typeStrings(unionMembers(mustParse("?(number | string)"))))
+	assert.Equal(t, []string{`"a | b"`, "{ [string]: number | string }"}, typeStrings(unionMembers(mustParse(`"a | b" | {[string]: number | string}`))))
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, []Change{{Type: "Product", Field: "id", Kind: "field-removed", Severity: Breaking, Detail: "field removed"}}))
+	assert.JSONEq(t, `{
+  "severity": "breaking",
+  "changes": [
+    {"type": "Product", "field": "id", "kind": "field-removed", "severity": "breaking", "detail": "field removed"}
+  ]
+}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteJSON(&buf, nil))
+	assert.JSONEq(t, `{"severity": "safe", "changes": []}`, buf.String())
+}
+
+// mustParse returns the type expression of a Flow type
+func mustParse(s string) *model.TypeExpr {
+	t, err := flow.ParseType(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
@@ -0,0 +1,78 @@
+package go2flow
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/kristiehoward/go2flow/flow"
+	"github.com/kristiehoward/go2flow/jsonschema"
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// Emitter prints the modules of the model in a target language. Output formats
+// are added by registering an EmitterFactory under the name of their language,
+// which Config.Language and the --lang flag then select.
+type Emitter interface {
+	// Emit writes the module of the file
+	Emit(w io.Writer, f *model.File) error
+	// FileName returns the name of the file the module is generated in, e.g.
+	// the file's Name with the extension of the language
+	FileName(f *model.File) string
+}
+
+// EmitterFactory returns the Emitter of a language configured by the options
+// of the config that concern it, e.g. the Format and Prologue of Flow modules
+type EmitterFactory func(cfg Config) Emitter
+
+var (
+	emittersMu sync.RWMutex
+	emitters   = map[string]EmitterFactory{
+		LanguageFlow: func(cfg Config) Emitter {
+			return flow.Emitter{Format: cfg.Format, Prologue: cfg.Prologue}
+		},
+		LanguageJSONSchema: func(Config) Emitter {
+			return jsonschema.Emitter{}
+		},
+	}
+)
+
+// RegisterEmitter registers the factory of the emitter of a target language,
+// replacing the one registered under the same name. It's typically called from
+// an init function.
+func RegisterEmitter(language string, factory EmitterFactory) {
+	if factory == nil {
+		panic("go2flow: RegisterEmitter of a nil factory for " + language)
+	}
+	emittersMu.Lock()
+	defer emittersMu.Unlock()
+	emitters[language] = factory
+}
+
+// Languages returns the sorted names of the registered target languages
+func Languages() []string {
+	emittersMu.RLock()
+	defer emittersMu.RUnlock()
+	names := make([]string, 0, len(emitters))
+	for name := range emitters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupEmitter returns the factory of the emitter of the target language,
+// which defaults to LanguageFlow
+func lookupEmitter(language string) (EmitterFactory, error) {
+	if language == "" {
+		language = LanguageFlow
+	}
+	emittersMu.RLock()
+	defer emittersMu.RUnlock()
+	factory, ok := emitters[language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported target language %q", language)
+	}
+	return factory, nil
+}
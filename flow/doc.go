@@ -0,0 +1,411 @@
+package flow
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// The printer lays out the modules the way Prettier does: each construct is
+// described as a doc of groups that are printed flat when they fit in the print
+// width, and broken over several lines otherwise. The docs and the algorithm
+// that prints them are Prettier's, so that the output is stable under it.
+
+// doc is a string, a []doc or one of the commands below
+type doc interface{}
+
+// group is printed flat if it fits, or else broken. A group with expanded
+// states is printed in the first state that fits, or else the last one.
+type group struct {
+	contents doc
+	brk      bool
+	id       int
+	expanded []doc
+}
+
+type indentDoc struct{ contents doc }
+
+// alignDoc indents its contents by n spaces
+type alignDoc struct {
+	n        int
+	contents doc
+}
+
+// lineDoc is a space, or a newline when its group is broken. A soft line is
+// empty rather than a space, and a hard line is always a newline.
+type lineDoc struct{ soft, hard bool }
+
+// ifBreak is printed as breakContents when the group with the ID, or else the
+// enclosing group, is broken, and as flatContents otherwise
+type ifBreak struct {
+	breakContents, flatContents doc
+	groupID                     int
+}
+
+// indentIfBreak indents its contents when the group with the ID is broken
+type indentIfBreak struct {
+	contents doc
+	groupID  int
+}
+
+// breakParent breaks the enclosing groups
+type breakParent struct{}
+
+var (
+	line     = lineDoc{}
+	softline = lineDoc{soft: true}
+	hardline = []doc{lineDoc{hard: true}, breakParent{}}
+)
+
+func newGroup(contents ...doc) *group {
+	return &group{contents: contents}
+}
+
+func indent(contents ...doc) indentDoc {
+	return indentDoc{contents: contents}
+}
+
+func align(n int, contents doc) alignDoc {
+	return alignDoc{n: n, contents: contents}
+}
+
+// join returns the docs with the separator between them
+func join(sep doc, docs []doc) []doc {
+	var joined []doc
+	for i, d := range docs {
+		if i > 0 {
+			joined = append(joined, sep)
+		}
+		joined = append(joined, d)
+	}
+	return joined
+}
+
+// mode is the mode of a group, zero for a group that isn't printed yet
+type mode int
+
+const (
+	modeBreak mode = iota + 1
+	modeFlat
+)
+
+// indentation is a level of indentation, made of indents and alignments
+type indentation struct {
+	value  string
+	length int
+	parts  []indentPart
+}
+
+type indentPart struct {
+	// n is the width of an alignment, 0 for an indent
+	n int
+}
+
+type command struct {
+	ind  *indentation
+	mode mode
+	doc  doc
+}
+
+// docPrinter prints docs with Prettier's algorithm
+type docPrinter struct {
+	width    int
+	tabWidth int
+	useTabs  bool
+
+	groupModes map[int]mode
+	nextID     int
+}
+
+// newID returns the ID of a group that other docs refer to
+func (p *docPrinter) newID() int {
+	p.nextID++
+	return p.nextID
+}
+
+func (p *docPrinter) makeIndent(ind *indentation, part indentPart) *indentation {
+	parts := append(append([]indentPart{}, ind.parts...), part)
+	var value strings.Builder
+	length, tabs, spaces := 0, 0, 0
+	flushSpaces := func() {
+		value.WriteString(strings.Repeat(" ", spaces))
+		length += spaces
+		tabs, spaces = 0, 0
+	}
+	flush := func() {
+		if p.useTabs && tabs > 0 {
+			value.WriteString(strings.Repeat("\t", tabs))
+			length += tabs * p.tabWidth
+			tabs, spaces = 0, 0
+			return
+		}
+		flushSpaces()
+	}
+	for _, part := range parts {
+		if part.n == 0 {
+			flush()
+			if p.useTabs {
+				value.WriteString("\t")
+			} else {
+				value.WriteString(strings.Repeat(" ", p.tabWidth))
+			}
+			length += p.tabWidth
+			continue
+		}
+		tabs++
+		spaces += part.n
+	}
+	flushSpaces()
+	return &indentation{value: value.String(), length: length, parts: parts}
+}
+
+// print returns the doc laid out in the print width
+func (p *docPrinter) print(d doc) string {
+	p.groupModes = map[int]mode{}
+	propagateBreaks(d)
+
+	var out []string
+	pos := 0
+	shouldRemeasure := false
+	cmds := []command{{ind: &indentation{}, mode: modeBreak, doc: d}}
+	for len(cmds) > 0 {
+		cmd := cmds[len(cmds)-1]
+		cmds = cmds[:len(cmds)-1]
+		switch d := cmd.doc.(type) {
+		case nil:
+		case string:
+			out = append(out, d)
+			pos += utf8.RuneCountInString(d)
+		case []doc:
+			for i := len(d) - 1; i >= 0; i-- {
+				cmds = append(cmds, command{cmd.ind, cmd.mode, d[i]})
+			}
+		case indentDoc:
+			cmds = append(cmds, command{p.makeIndent(cmd.ind, indentPart{}), cmd.mode, d.contents})
+		case alignDoc:
+			cmds = append(cmds, command{p.makeIndent(cmd.ind, indentPart{n: d.n}), cmd.mode, d.contents})
+		case *group:
+			if cmd.mode == modeFlat && !shouldRemeasure {
+				m := modeFlat
+				if d.brk {
+					m = modeBreak
+				}
+				cmds = append(cmds, command{cmd.ind, m, d.contents})
+			} else {
+				shouldRemeasure = false
+				next := command{cmd.ind, modeFlat, d.contents}
+				rem := p.width - pos
+				switch {
+				case !d.brk && p.fits(next, cmds, rem, false):
+					cmds = append(cmds, next)
+				case len(d.expanded) > 0:
+					mostExpanded := d.expanded[len(d.expanded)-1]
+					if d.brk {
+						cmds = append(cmds, command{cmd.ind, modeBreak, mostExpanded})
+						break
+					}
+					for i := 1; i < len(d.expanded)+1; i++ {
+						if i >= len(d.expanded) {
+							cmds = append(cmds, command{cmd.ind, modeBreak, mostExpanded})
+							break
+						}
+						state := command{cmd.ind, modeFlat, d.expanded[i]}
+						if p.fits(state, cmds, rem, false) {
+							cmds = append(cmds, state)
+							break
+						}
+					}
+				default:
+					cmds = append(cmds, command{cmd.ind, modeBreak, d.contents})
+				}
+			}
+			if d.id != 0 {
+				p.groupModes[d.id] = cmds[len(cmds)-1].mode
+			}
+		case ifBreak:
+			m := cmd.mode
+			if d.groupID != 0 {
+				m = p.groupModes[d.groupID]
+			}
+			switch m {
+			case modeBreak:
+				cmds = append(cmds, command{cmd.ind, cmd.mode, d.breakContents})
+			case modeFlat:
+				cmds = append(cmds, command{cmd.ind, cmd.mode, d.flatContents})
+			}
+		case indentIfBreak:
+			switch p.groupModes[d.groupID] {
+			case modeBreak:
+				cmds = append(cmds, command{cmd.ind, cmd.mode, indent(d.contents)})
+			case modeFlat:
+				cmds = append(cmds, command{cmd.ind, cmd.mode, d.contents})
+			}
+		case lineDoc:
+			if cmd.mode == modeFlat && !d.hard {
+				if !d.soft {
+					out = append(out, " ")
+					pos++
+				}
+				break
+			}
+			if cmd.mode == modeFlat {
+				// The line was forced into a flat group, so the next group
+				// has to be measured again
+				shouldRemeasure = true
+			}
+			trimTrailing(out)
+			out = append(out, "\n"+cmd.ind.value)
+			pos = cmd.ind.length
+		case breakParent:
+		}
+	}
+	return strings.Join(out, "")
+}
+
+// fits reports whether the next command, followed by the rest of the line from
+// the remaining commands, fits in the width
+func (p *docPrinter) fits(next command, rest []command, width int, mustBeFlat bool) bool {
+	restIdx := len(rest)
+	cmds := []command{next}
+	for width >= 0 {
+		if len(cmds) == 0 {
+			if restIdx == 0 {
+				return true
+			}
+			restIdx--
+			cmds = append(cmds, rest[restIdx])
+			continue
+		}
+		cmd := cmds[len(cmds)-1]
+		cmds = cmds[:len(cmds)-1]
+		switch d := cmd.doc.(type) {
+		case string:
+			width -= utf8.RuneCountInString(d)
+		case []doc:
+			for i := len(d) - 1; i >= 0; i-- {
+				cmds = append(cmds, command{cmd.ind, cmd.mode, d[i]})
+			}
+		case indentDoc:
+			cmds = append(cmds, command{cmd.ind, cmd.mode, d.contents})
+		case alignDoc:
+			cmds = append(cmds, command{cmd.ind, cmd.mode, d.contents})
+		case indentIfBreak:
+			cmds = append(cmds, command{cmd.ind, cmd.mode, d.contents})
+		case *group:
+			if mustBeFlat && d.brk {
+				return false
+			}
+			m := cmd.mode
+			if d.brk {
+				m = modeBreak
+			}
+			contents := d.contents
+			if len(d.expanded) > 0 && m == modeBreak {
+				contents = d.expanded[len(d.expanded)-1]
+			}
+			cmds = append(cmds, command{cmd.ind, m, contents})
+		case ifBreak:
+			m := cmd.mode
+			if d.groupID != 0 {
+				var ok bool
+				if m, ok = p.groupModes[d.groupID]; !ok {
+					m = modeFlat
+				}
+			}
+			if m == modeBreak {
+				cmds = append(cmds, command{cmd.ind, cmd.mode, d.breakContents})
+			} else {
+				cmds = append(cmds, command{cmd.ind, cmd.mode, d.flatContents})
+			}
+		case lineDoc:
+			if cmd.mode == modeBreak || d.hard {
+				return true
+			}
+			if !d.soft {
+				width--
+			}
+		}
+	}
+	return false
+}
+
+// trimTrailing removes the trailing spaces and tabs of the output
+func trimTrailing(out []string) {
+	for i := len(out) - 1; i >= 0; i-- {
+		trimmed := strings.TrimRight(out[i], " \t")
+		out[i] = trimmed
+		if trimmed != "" {
+			return
+		}
+	}
+}
+
+// propagateBreaks breaks the groups that contain a hard line or a broken
+// group, except groups with expanded states, which decide for themselves
+func propagateBreaks(d doc) {
+	var walk func(d doc) bool
+	walk = func(d doc) bool {
+		switch d := d.(type) {
+		case []doc:
+			brk := false
+			for _, child := range d {
+				if walk(child) {
+					brk = true
+				}
+			}
+			return brk
+		case indentDoc:
+			return walk(d.contents)
+		case alignDoc:
+			return walk(d.contents)
+		case indentIfBreak:
+			return walk(d.contents)
+		case ifBreak:
+			b := walk(d.breakContents)
+			f := walk(d.flatContents)
+			return b || f
+		case *group:
+			brk := walk(d.contents)
+			for _, state := range d.expanded {
+				walk(state)
+			}
+			if brk && len(d.expanded) == 0 {
+				d.brk = true
+			}
+			return d.brk
+		case breakParent:
+			return true
+		}
+		return false
+	}
+	walk(d)
+}
+
+// willBreak reports whether the doc contains a forced break
+func willBreak(d doc) bool {
+	switch d := d.(type) {
+	case []doc:
+		for _, child := range d {
+			if willBreak(child) {
+				return true
+			}
+		}
+	case indentDoc:
+		return willBreak(d.contents)
+	case alignDoc:
+		return willBreak(d.contents)
+	case indentIfBreak:
+		return willBreak(d.contents)
+	case ifBreak:
+		return willBreak(d.breakContents) || willBreak(d.flatContents)
+	case *group:
+		if d.brk {
+			return true
+		}
+		return willBreak(d.contents)
+	case lineDoc:
+		return d.hard
+	case breakParent:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,374 @@
+// Package flow prints the modules of the model as Flow
+package flow
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// Emitter prints the modules of the model as Flow, laid out as Prettier does
+// with the options of the Format, after the Prologue
+type Emitter struct {
+	Format   Format
+	Prologue Prologue
+}
+
+// Emit writes the Flow module of the file
+func (e Emitter) Emit(w io.Writer, f *model.File) error {
+	format, err := e.Format.withDefaults()
+	if err != nil {
+		return err
+	}
+	if err := e.Prologue.validate(); err != nil {
+		return err
+	}
+	p := &printer{
+		format: format,
+		docs:   &docPrinter{width: format.PrintWidth, tabWidth: format.TabWidth, useTabs: format.UseTabs},
+	}
+	for _, line := range e.Prologue.lines(f) {
+		p.out.WriteString(line + "\n")
+	}
+	p.imports(f.Imports)
+	if len(f.Routes) > 0 || len(f.Services) > 0 {
+		p.prelude()
+		for _, s := range f.Services {
+			p.service(s)
+		}
+		for _, r := range f.Routes {
+			p.route(r)
+		}
+	}
+	for _, d := range f.Decls {
+		if d.Type != nil {
+			p.typeDef(d.Type)
+		} else {
+			p.consts(d.Consts)
+		}
+	}
+	_, err = io.WriteString(w, p.out.String())
+	return err
+}
+
+// FileName returns the name of the file's module, which is already a .js file
+func (e Emitter) FileName(f *model.File) string {
+	return f.Name
+}
+
+// Print writes the Flow module of the file in Prettier's default layout
+func Print(w io.Writer, f *model.File) error {
+	return Emitter{}.Emit(w, f)
+}
+
+type printer struct {
+	format Format
+	docs   *docPrinter
+	out    strings.Builder
+}
+
+// statement writes a top level statement, separated from the previous one by a
+// blank line
+func (p *printer) statement(d doc) {
+	if p.out.Len() > 0 {
+		p.out.WriteString("\n")
+	}
+	p.next(d)
+}
+
+// next writes a top level statement on the line after the previous one
+func (p *printer) next(d doc) {
+	p.out.WriteString(p.docs.print(d))
+	p.out.WriteString("\n")
+}
+
+// imports writes the import declarations of the types of other modules
+func (p *printer) imports(imports []*model.Import) {
+	for i, imp := range imports {
+		specifiers := make([]doc, len(imp.Names))
+		for i, name := range imp.Names {
+			specifiers[i] = name.Name
+			if name.As != "" {
+				specifiers[i] = fmt.Sprintf("%s as %s", name.Name, name.As)
+			}
+		}
+		var braces doc = []doc{"{ ", specifiers, " }"}
+		if len(specifiers) > 1 {
+			braces = newGroup("{", indent(line, join([]doc{",", line}, specifiers)), p.format.comma(true), line, "}")
+		}
+		d := []doc{"import type ", braces, " from ", p.format.quote(imp.Module), p.format.semi()}
+		if i == 0 {
+			p.statement(d)
+		} else {
+			p.next(d)
+		}
+	}
+}
+
+func (p *printer) typeDef(t *model.Type) {
+	switch t.Kind {
+	case model.KindStruct:
+		p.structType(t)
+	case model.KindEnum:
+		if t.Runtime {
+			p.enumObject(t)
+		} else {
+			p.enumUnion(t)
+		}
+	case model.KindOpaque:
+		p.opaqueType(t)
+	default:
+		p.statement(p.typeAlias(t.Name, toFlow(t.Type)))
+	}
+}
+
+// typeAlias returns an exported type alias declaration
+func (p *printer) typeAlias(name string, t flowType) doc {
+	layout := layoutFluid
+	if l, ok := t.(literalType); ok {
+		layout = literalLayout(l.value)
+	}
+	return []doc{p.assignment("export type "+name, " =", p.flowTypeDoc(t, inDeclaration), layout), p.format.semi()}
+}
+
+func (p *printer) structType(t *model.Type) {
+	obj := objectType{}
+	for _, f := range t.Fields {
+		obj.props = append(obj.props, fieldProp(f))
+	}
+	p.statement([]doc{p.assignment("export type "+t.Name, " =", p.objectTypeDoc(obj, len(obj.props) > 0), layoutFluid), p.format.semi()})
+}
+
+// constraintTags returns the JSDoc tags of a property's constraints, named after
+// their JSON Schema keywords, e.g. `@maxLength 64`
+func constraintTags(c *model.Constraints) []string {
+	if c == nil {
+		return nil
+	}
+	var tags []string
+	number := func(keyword string, n *float64) {
+		if n != nil {
+			tags = append(tags, fmt.Sprintf("@%s %s", keyword, strconv.FormatFloat(*n, 'f', -1, 64)))
+		}
+	}
+	length := func(keyword string, n *int) {
+		if n != nil {
+			tags = append(tags, fmt.Sprintf("@%s %d", keyword, *n))
+		}
+	}
+	number("minimum", c.Minimum)
+	number("exclusiveMinimum", c.ExclusiveMinimum)
+	number("maximum", c.Maximum)
+	number("exclusiveMaximum", c.ExclusiveMaximum)
+	length("minLength", c.MinLength)
+	length("maxLength", c.MaxLength)
+	length("minItems", c.MinItems)
+	length("maxItems", c.MaxItems)
+	length("minProperties", c.MinProperties)
+	length("maxProperties", c.MaxProperties)
+	return tags
+}
+
+// enumUnion writes an enum type as the union of its values
+func (p *printer) enumUnion(t *model.Type) {
+	var members []flowType
+	for _, lit := range t.Literals() {
+		members = append(members, toFlow(lit))
+	}
+	switch len(members) {
+	case 0:
+		p.statement(p.typeAlias(t.Name, namedType{name: "empty"}))
+	case 1:
+		p.statement(p.typeAlias(t.Name, members[0]))
+	default:
+		p.statement(p.typeAlias(t.Name, unionType{members: members}))
+	}
+}
+
+// enumObject writes an enum type along with its values at runtime: a frozen
+// object of the values by key, a frozen array of the values and their labels
+// for selects and filters, and the type of the values derived from the object.
+// A Flow type can't share its name with a value, so the object is named after
+// the type with a Values suffix.
+func (p *printer) enumObject(t *model.Type) {
+	name := t.Name
+	values := make([]doc, len(t.Values))
+	options := make([]doc, len(t.Values))
+	for i, v := range t.Values {
+		values[i] = p.property(v.Key, p.format.literalText(v.Value), literalLayout(v.Value))
+		options[i] = p.object(false,
+			p.property("value", name+"Values."+v.Key, layoutBreakAfterOperator),
+			p.property("label", p.format.quote(v.Label), layoutBreakAfterOperator),
+		)
+	}
+	freeze := func(arg doc) doc { return p.call("Object.freeze", []doc{arg}, true) }
+	p.statement(p.constant(name+"Values", freeze(p.object(true, values...)), layoutFluid))
+	p.statement(p.typeAlias(name, namedType{name: "$Values", args: []flowType{typeofType{name: name + "Values"}}}))
+
+	option := objectType{exact: true, props: []objectProp{
+		{key: "value", value: namedType{name: name}},
+		{key: "label", value: namedType{name: "string"}},
+	}}
+	annotated := []doc{name + "Options: ", p.flowTypeDoc(namedType{name: "$ReadOnlyArray", args: []flowType{option}}, inDeclaration)}
+	p.statement(p.constant(annotated, freeze(p.array(options)), layoutFluid))
+}
+
+// opaqueType writes an opaque type for a type defined from a primitive, so that
+// it stays nominal in JS, along with the helpers that create values of it: from
+// the primitive, or by validating a value, which returns it typed as the opaque
+// type rather than only refining it to the primitive. Only the defining module
+// can see through an opaque type, so the helpers are defined alongside it.
+// https://flow.org/en/docs/types/opaque-types/
+func (p *printer) opaqueType(t *model.Type) {
+	name, flowType := t.Name, p.typeDoc(t.Type)
+	semi, typeName := p.format.semi(), p.format.quote(t.Type.Name)
+	p.statement([]doc{"export opaque type ", name, ": ", flowType, " = ", flowType, semi})
+	p.statement(p.function("export function to"+name, []doc{[]doc{"value: ", flowType}}, name,
+		"return value"+semi,
+	))
+	p.statement(p.function("export function as"+name, []doc{"value: mixed"}, "?"+name,
+		p.ifStatement("typeof value === "+typeName, "return value"+semi),
+		"return null"+semi,
+	))
+	p.statement(p.function("export function assert"+name, []doc{"value: mixed"}, name,
+		p.ifStatement("typeof value !== "+typeName,
+			[]doc{"throw new TypeError", p.call("", []doc{p.format.quote(fmt.Sprintf("Expected %s to be a %s", name, t.Type.Name))}, false), semi},
+		),
+		"return value"+semi,
+	))
+}
+
+// consts writes the constants of a const declaration as JS constants whose Flow
+// types are their literal values
+func (p *printer) consts(consts []*model.Const) {
+	for i, c := range consts {
+		lit := p.format.literalText(c.Value)
+		d := p.constant(c.Name+": "+lit, lit, literalLayout(c.Value))
+		if i == 0 {
+			p.statement(d)
+		} else {
+			p.next(d)
+		}
+	}
+}
+
+// prelude writes the request helper of a client module, which the route
+// functions and service clients call. It's laid out for print widths of about
+// 80 and more; narrower widths only wrap its signature, error and conditions.
+func (p *printer) prelude() {
+	semi, q := p.format.semi(), p.format.quote
+	options := objectType{props: []objectProp{
+		{key: "baseURL", optional: true, value: namedType{name: "string"}},
+		{key: "headers", optional: true, value: toFlow(model.MapOf(model.Primitive(model.String), model.Primitive(model.String)))},
+		{key: "signal", optional: true, value: namedType{name: "AbortSignal"}},
+	}}
+	p.statement([]doc{p.assignment("export type RequestOptions", " =", p.objectTypeDoc(options, true), layoutFluid), semi})
+
+	var concat []doc
+	for _, operand := range []string{q(" "), "path", q(": "), "response.status", q(" "), "response.statusText"} {
+		concat = append(concat, " ", []doc{"+", line, operand})
+	}
+	noContent := []doc{
+		newGroup("response.status", " ", newGroup("===", line, "204")),
+		" ",
+		newGroup("||", line, newGroup(
+			newGroup("response.headers.get", p.call("", []doc{q("Content-Length")}, false)),
+			" ",
+			newGroup("===", line, q("0")),
+		)),
+	}
+	fetch := p.call("fetch", []doc{"url", p.object(true,
+		"method",
+		"headers",
+		p.property("body", "body === undefined ? undefined : JSON.stringify(body)", layoutBreakAfterOperator),
+		p.property("signal", "options.signal", layoutBreakAfterOperator),
+	)}, true)
+
+	p.statement(p.function("async function request<T>",
+		[]doc{"method: string", "path: string", "query: mixed", "body: mixed", "options: RequestOptions"},
+		"Promise<T>",
+		"let url = (options.baseURL || "+q("")+") + path"+semi,
+		p.ifStatement("query != null",
+			"const params = new URLSearchParams()"+semi,
+			"const values: { [string]: mixed } = (query: any)"+semi,
+			p.forStatement("const key of Object.keys(values)",
+				"const value = values[key]"+semi,
+				p.forStatement("const v of Array.isArray(value) ? value : [value]",
+					p.ifStatement("v != null", "params.append(key, String(v))"+semi),
+				),
+			),
+			"const search = params.toString()"+semi,
+			p.ifStatement("search !== "+q(""), "url += "+q("?")+" + search"+semi),
+		),
+		"const headers = { ...options.headers }"+semi,
+		p.ifStatement("body !== undefined", "headers["+q("Content-Type")+"] = "+q("application/json")+semi),
+		[]doc{p.assignment("const response", " =", []doc{"await ", fetch}, layoutFluid), semi},
+		p.ifStatement("!response.ok",
+			[]doc{"throw new Error", p.call("", []doc{newGroup("method", indent(concat...))}, false), semi},
+		),
+		p.ifStatement(noContent, "return (undefined: any)"+semi),
+		"return response.json()"+semi,
+	))
+}
+
+// route writes the client function of a route, which fetches the route with
+// the path parameters, request and response typed
+func (p *printer) route(r *model.Route) {
+	var params []doc
+	path, names := routePath(r)
+	for _, name := range names {
+		params = append(params, name+": string")
+	}
+
+	query, body := "undefined", "undefined"
+	if r.Request != nil {
+		if r.Query {
+			params = append(params, []doc{"query: ", p.typeDoc(r.Request)})
+			query = "query"
+		} else {
+			params = append(params, []doc{"body: ", p.typeDoc(r.Request)})
+			body = "body"
+		}
+	}
+	params = append(params, "options: RequestOptions = {}")
+
+	args := []doc{p.format.quote(r.Method), path, query, body, "options"}
+	p.statement(p.function("export async function "+r.Name, params, p.promise(r.Response),
+		[]doc{"return ", p.call("request", args, false), p.format.semi()},
+	))
+}
+
+// service writes the map of a service's methods to their paths, and a client
+// class with a method per RPC
+func (p *printer) service(s *model.Service) {
+	semi := p.format.semi()
+	paths := make([]doc, len(s.Methods))
+	for i, rpc := range s.Methods {
+		paths[i] = p.property(rpc.Name, p.format.quote(s.PathPrefix+rpc.Name), layoutBreakAfterOperator)
+	}
+	p.statement(p.constant(s.Name+"Paths", p.call("Object.freeze", []doc{p.object(true, paths...)}, true), layoutFluid))
+
+	members := []doc{
+		"options: RequestOptions" + semi,
+		p.function("constructor", []doc{"options: RequestOptions = {}"}, nil,
+			"this.options = options"+semi,
+		),
+	}
+	for _, rpc := range s.Methods {
+		args := []doc{p.format.quote("POST"), s.Name + "Paths." + rpc.Name, "undefined", "body", p.object(false, "...this.options", "...options")}
+		members = append(members, p.function(rpc.Method,
+			[]doc{[]doc{"body: ", p.typeDoc(rpc.Request)}, "options: RequestOptions = {}"},
+			p.promise(rpc.Response),
+			[]doc{"return ", p.call("request", args, true), semi},
+		))
+	}
+	p.statement([]doc{"export class ", s.Name, "Client {", indent(hardline, join([]doc{hardline, hardline}, members)), hardline, "}"})
+}
+
+// promise returns the doc of the Promise of a type, of void without one
+func (p *printer) promise(t *model.TypeExpr) doc {
+	return p.flowTypeDoc(namedType{name: "Promise", args: []flowType{toFlow(t)}}, inDeclaration)
+}
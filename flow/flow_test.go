@@ -0,0 +1,213 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func emit(t *testing.T, format Format, f *model.File) string {
+	var buf bytes.Buffer
+	require.NoError(t, Emitter{Format: format}.Emit(&buf, f))
+	return buf.String()
+}
+
+// mustParse returns the type expression of a Flow type
+func mustParse(s string) *model.TypeExpr {
+	t, err := ParseType(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestUnionWrapping(t *testing.T) {
+	f := &model.File{Decls: []*model.Decl{
+		{Type: &model.Type{Name: "Region", Kind: model.KindEnum, Values: []*model.EnumValue{
+			{Value: "us-east-1"}, {Value: "us-west-2"}, {Value: "eu-central-1"}, {Value: "ap-southeast-2"},
+		}}},
+		{Type: &model.Type{Name: "Deployment", Kind: model.KindStruct, Fields: []*model.Field{
+			{Name: "region", Type: mustParse(`"us-east-1" | "us-west-2" | "eu-central-1" | "ap-southeast-2"`)},
+			{Name: "replicas", Type: mustParse("Array<{ [string]: ?(DeploymentReplicaStatus | DeploymentReplicaError) }>")},
+			{Name: "content-type", Type: mustParse("string"), Nullable: true},
+		}}},
+	}}
+	assert.Equal(t, `export type Region =
+  | "us-east-1"
+  | "us-west-2"
+  | "eu-central-1"
+  | "ap-southeast-2";
+
+export type Deployment = {
+  region: "us-east-1" | "us-west-2" | "eu-central-1" | "ap-southeast-2",
+  replicas: Array<{
+    [string]: ?(DeploymentReplicaStatus | DeploymentReplicaError),
+  }>,
+  "content-type": ?string,
+};
+`, emit(t, Format{}, f))
+
+	assert.Equal(t, `export type Region =
+  | "us-east-1"
+  | "us-west-2"
+  | "eu-central-1"
+  | "ap-southeast-2";
+
+export type Deployment = {
+  region:
+    | "us-east-1"
+    | "us-west-2"
+    | "eu-central-1"
+    | "ap-southeast-2",
+  replicas: Array<{
+    [string]: ?(
+      | DeploymentReplicaStatus
+      | DeploymentReplicaError
+    ),
+  }>,
+  "content-type": ?string,
+};
+`, emit(t, Format{PrintWidth: 60}, f))
+}
+
+func TestFormatOptions(t *testing.T) {
+	f := &model.File{
+		Imports: []*model.Import{{Module: "./types", Names: []*model.ImportName{{Name: "Product"}}}},
+		Routes: []*model.Route{
+			{Name: "getProduct", Method: "GET", Path: "/products/{id}", Response: mustParse("Product")},
+		},
+		Decls: []*model.Decl{
+			{Type: &model.Type{Name: "Status", Kind: model.KindEnum, Runtime: true, Values: []*model.EnumValue{
+				{Key: "Active", Value: "active", Label: "It's live"}, {Key: "Retired", Value: "retired", Label: "Retired"},
+			}}},
+			{Consts: []*model.Const{{Name: "Ratio", Value: json.Number("1.50")}, {Name: "Greeting", Value: "it's"}}},
+		},
+	}
+	out := emit(t, Format{UseTabs: true, SingleQuote: true, TrailingComma: TrailingCommaNone, NoSemi: true}, f)
+	assert.Contains(t, out, "import type { Product } from './types'\n")
+	assert.Contains(t, out, `export async function getProduct(
+	id: string,
+	options: RequestOptions = {}
+): Promise<Product> {
+	return request(
+		'GET',
+		`+"`/products/${encodeURIComponent(id)}`"+`,
+		undefined,
+		undefined,
+		options
+	)
+}
+`)
+	assert.Contains(t, out, `export const StatusValues = Object.freeze({
+	Active: 'active',
+	Retired: 'retired'
+})
+
+export type Status = $Values<typeof StatusValues>
+
+export const StatusOptions: $ReadOnlyArray<{| value: Status, label: string |}> =
+	Object.freeze([
+		{ value: StatusValues.Active, label: "It's live" },
+		{ value: StatusValues.Retired, label: 'Retired' }
+	])
+
+export const Ratio: 1.5 = 1.5
+export const Greeting: "it's" = "it's"
+`)
+
+	out = emit(t, Format{TabWidth: 4, TrailingComma: TrailingCommaES5}, f)
+	assert.Contains(t, out, `export async function getProduct(
+    id: string,
+    options: RequestOptions = {}
+): Promise<Product> {`)
+	assert.Contains(t, out, `    Retired: "retired",
+});`)
+
+	_, err := Format{TrailingComma: "always"}.withDefaults()
+	assert.Error(t, err)
+}
+
+func TestRoutePathParams(t *testing.T) {
+	f := &model.File{Routes: []*model.Route{
+		{Name: "getPart", Method: "POST", Path: "/p/{product-id}/{body}/{options}/{class}/{id}/:id/a`b/{rest...}", Request: mustParse("Part")},
+	}}
+	out := emit(t, Format{}, f)
+	assert.Contains(t, out, `export async function getPart(
+  productId: string,
+  bodyParam: string,
+  optionsParam: string,
+  classParam: string,
+  id: string,
+  idParam: string,
+  rest: string,
+  body: Part,
+  options: RequestOptions = {},
+): Promise<void> {
+  return request(
+    "POST",
+    `+"`/p/${encodeURIComponent(productId)}/${encodeURIComponent(bodyParam)}/${encodeURIComponent(optionsParam)}/${encodeURIComponent(classParam)}/${encodeURIComponent(id)}/${encodeURIComponent(idParam)}/a\\`b/${encodeURI(rest)}`"+`,
+    undefined,
+    body,
+    options,
+  );
+}
+`)
+}
+
+func TestParseType(t *testing.T) {
+	for _, c := range []struct{ in, out string }{
+		{"{[string]: string}", "{ [string]: string }"},
+		{"{[key: string]: number}", "{ [string]: number }"},
+		{"{|id: string, 'x-y'?: number, owner: ?string|}", `{| id: string, "x-y"?: number, owner: ?string |}`},
+		{"?string[]", "?Array<string>"},
+		{"(?string)[]", "Array<?string>"},
+		{"Array<'a'|'b'>", `Array<"a" | "b">`},
+		{"[number,number]", "[number, number]"},
+		{"mixed | empty | null | 1.50", "mixed | empty | null | 1.5"},
+		{"Map<string, Product>", "Map<string, Product>"},
+	} {
+		typ, err := ParseType(c.in)
+		require.NoError(t, err, c.in)
+		assert.Equal(t, c.out, TypeString(typ), c.in)
+	}
+
+	typ, err := ParseType("?{[string]: mixed}")
+	require.NoError(t, err)
+	assert.Equal(t, model.NullableOf(model.MapOf(model.Primitive(model.String), model.Primitive(model.Unknown))), typ)
+
+	for _, in := range []string{"$Values<typeof Status>", "(string) => void", "Array<string", ""} {
+		_, err := ParseType(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestPrologue(t *testing.T) {
+	f := &model.File{Source: "catalog (api/catalog)", Decls: []*model.Decl{
+		{Type: &model.Type{Name: "ProductID", Kind: model.KindAlias, Type: mustParse("string")}},
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, Emitter{Prologue: Prologue{
+		Pragma:        PragmaFlowStrict,
+		Generated:     true,
+		Command:       "go generate ./api/...",
+		ESLintDisable: []string{"", "flowtype/require-exact-type, no-unused-vars"},
+	}}.Emit(&buf, f))
+	assert.Equal(t, `// @flow strict
+// @generated by go2flow from catalog (api/catalog), do not edit
+// Regenerate with: go generate ./api/...
+/* eslint-disable */
+/* eslint-disable flowtype/require-exact-type, no-unused-vars */
+
+export type ProductID = string;
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, Emitter{Prologue: Prologue{Pragma: PragmaFlow}}.Emit(&buf, &model.File{}))
+	assert.Equal(t, "// @flow\n", buf.String())
+
+	assert.Error(t, Emitter{Prologue: Prologue{Pragma: "@noflow"}}.Emit(&buf, f))
+}
@@ -0,0 +1,202 @@
+package flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format is the layout of the printed modules, named after the Prettier
+// options that produce the same layout. The zero value is Prettier's default
+// layout.
+type Format struct {
+	// TabWidth is the number of spaces per indentation level, 2 by default
+	TabWidth int
+	// UseTabs indents with tabs rather than spaces
+	UseTabs bool
+	// SingleQuote quotes strings with single rather than double quotes
+	SingleQuote bool
+	// TrailingComma is where trailing commas are printed in broken lists:
+	// "all" (the default), "es5" or "none"
+	TrailingComma string
+	// NoSemi leaves out the semicolons at the end of statements
+	NoSemi bool
+	// PrintWidth is the line length that lists and unions are wrapped at, 80
+	// by default
+	PrintWidth int
+}
+
+// Trailing comma options
+const (
+	TrailingCommaAll  = "all"
+	TrailingCommaES5  = "es5"
+	TrailingCommaNone = "none"
+)
+
+// withDefaults returns the format with Prettier's defaults for the unset
+// options
+func (f Format) withDefaults() (Format, error) {
+	if f.TabWidth <= 0 {
+		f.TabWidth = 2
+	}
+	if f.PrintWidth <= 0 {
+		f.PrintWidth = 80
+	}
+	switch f.TrailingComma {
+	case "":
+		f.TrailingComma = TrailingCommaAll
+	case TrailingCommaAll, TrailingCommaES5, TrailingCommaNone:
+	default:
+		return f, fmt.Errorf("unsupported trailing comma option %q, expected all, es5 or none", f.TrailingComma)
+	}
+	return f, nil
+}
+
+// comma returns the trailing comma of a broken list, where it's allowed by ES5
+// when es5 is set, or else only by more recent editions
+func (f Format) comma(es5 bool) doc {
+	if f.TrailingComma == TrailingCommaAll || es5 && f.TrailingComma == TrailingCommaES5 {
+		return ifBreak{breakContents: ","}
+	}
+	return ""
+}
+
+func (f Format) semi() string {
+	if f.NoSemi {
+		return ""
+	}
+	return ";"
+}
+
+// quote returns the JS literal of a string, in the preferred quotes unless the
+// string contains more of them than of the other quotes
+func (f Format) quote(s string) string {
+	enclosing, other := '"', '\''
+	if f.SingleQuote {
+		enclosing, other = other, enclosing
+	}
+	if strings.Count(s, string(enclosing)) > strings.Count(s, string(other)) {
+		enclosing = other
+	}
+
+	var b strings.Builder
+	b.WriteRune(enclosing)
+	for _, r := range s {
+		switch {
+		case r == enclosing || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case r == '\u2028' || r == '\u2029':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteRune(enclosing)
+	return b.String()
+}
+
+// literalText returns the JS literal of a literal value of the model
+func (f Format) literalText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return f.quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return normalizeNumber(strconv.FormatFloat(v, 'g', -1, 64))
+	case json.Number:
+		return normalizeNumber(string(v))
+	}
+	return "null"
+}
+
+// normalizeNumber prints a number as Prettier does: lower case, without
+// trailing zeros or a trailing dot, and without a plus sign or leading zeros in
+// the exponent
+func normalizeNumber(n string) string {
+	n = strings.ToLower(n)
+	for _, r := range numberRewrites {
+		n = r.re.ReplaceAllString(n, r.repl)
+	}
+	return n
+}
+
+var numberRewrites = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`^([+-]?[\d.]+e)(?:\+|(-))?0*(\d)`), "${1}${2}${3}"},
+	{regexp.MustCompile(`^([+-]?[\d.]+)e[+-]?0+$`), "${1}"},
+	{regexp.MustCompile(`^([+-])?\.`), "${1}0."},
+	{regexp.MustCompile(`(\.\d+?)0+(e|$)`), "${1}${2}"},
+	{regexp.MustCompile(`\.(e|$)`), "${1}"},
+}
+
+// unquoteJS returns the value of a single or double quoted JS string literal
+func unquoteJS(lit string) (string, bool) {
+	if len(lit) < 2 || lit[0] != lit[len(lit)-1] || lit[0] != '"' && lit[0] != '\'' {
+		return "", false
+	}
+	var b strings.Builder
+	body := lit[1 : len(lit)-1]
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c == lit[0] {
+			return "", false
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(body) {
+			return "", false
+		}
+		switch e := body[i]; e {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case 'x', 'u':
+			n := 2
+			if e == 'u' {
+				n = 4
+			}
+			if i+n >= len(body) {
+				return "", false
+			}
+			v, err := strconv.ParseUint(body[i+1:i+1+n], 16, 32)
+			if err != nil {
+				return "", false
+			}
+			b.WriteRune(rune(v))
+			i += n
+		default:
+			b.WriteByte(e)
+		}
+	}
+	return b.String(), true
+}
@@ -0,0 +1,140 @@
+package flow
+
+import "unicode/utf8"
+
+// The helpers below return the docs of the JS constructs of the modules, with
+// the layouts that Prettier gives them
+
+// assignmentLayout is how a declaration breaks when it doesn't fit on a line
+type assignmentLayout int
+
+const (
+	// layoutFluid moves the value to the next line only if that makes it fit,
+	// and otherwise breaks the value itself
+	layoutFluid assignmentLayout = iota
+	// layoutBreakAfterOperator moves the value to the next line, for values
+	// such as strings that can't break
+	layoutBreakAfterOperator
+	// layoutNeverBreak keeps the value on the line of a short key
+	layoutNeverBreak
+)
+
+// assignment lays out a declaration, e.g. `export type T =` and its type, or a
+// property and its value with the : operator
+func (p *printer) assignment(left doc, op string, right doc, layout assignmentLayout) doc {
+	switch layout {
+	case layoutBreakAfterOperator:
+		return newGroup(newGroup(left), op, newGroup(indent(line, right)))
+	case layoutNeverBreak:
+		return newGroup(newGroup(left), op, " ", right)
+	}
+	id := p.docs.newID()
+	return newGroup(newGroup(left), op, &group{contents: indent(line), id: id}, indentIfBreak{contents: right, groupID: id})
+}
+
+// constant returns an exported const declaration
+func (p *printer) constant(left, right doc, layout assignmentLayout) doc {
+	return []doc{p.assignment([]doc{"export const ", left}, " =", right, layout), p.format.semi()}
+}
+
+// literalLayout returns the layout of a declaration of a literal value
+func literalLayout(value interface{}) assignmentLayout {
+	if _, ok := value.(string); ok {
+		return layoutBreakAfterOperator
+	}
+	return layoutFluid
+}
+
+// property returns an object property. Values are kept on the line of keys
+// shorter than an indent, which gains little from moving them.
+func (p *printer) property(key string, value doc, layout assignmentLayout) doc {
+	key = p.propertyKey(key)
+	if utf8.RuneCountInString(key) < p.format.TabWidth+3 {
+		layout = layoutNeverBreak
+	}
+	return p.assignment(key, ":", value, layout)
+}
+
+// object returns an object literal, broken over several lines when expanded is
+// set
+func (p *printer) object(expanded bool, props ...doc) doc {
+	if len(props) == 0 {
+		return "{}"
+	}
+	g := newGroup("{", indent(line, join([]doc{",", line}, props)), p.format.comma(true), line, "}")
+	g.brk = expanded
+	return g
+}
+
+// array returns an array literal of objects, which has an element per line
+// when there are several
+func (p *printer) array(elems []doc) doc {
+	if len(elems) == 0 {
+		return "[]"
+	}
+	g := newGroup("[", indent(softline, join([]doc{",", line}, elems)), p.format.comma(true), softline, "]")
+	g.brk = len(elems) > 1
+	return g
+}
+
+// call returns a call with the arguments on one line, or else one per line. A
+// last object or array argument is hugged when hugLast is set: only it is
+// broken if that makes the call fit.
+func (p *printer) call(callee doc, args []doc, hugLast bool) doc {
+	if len(args) == 0 {
+		return []doc{callee, "()"}
+	}
+	printed := make([]doc, len(args))
+	for i, a := range args {
+		printed[i] = a
+		if i < len(args)-1 {
+			printed[i] = []doc{a, ",", line}
+		}
+	}
+	allBroken := &group{contents: []doc{"(", indent(line, printed, p.format.comma(false)), line, ")"}, brk: true}
+	if !hugLast {
+		return []doc{callee, newGroup("(", indent(softline, printed), p.format.comma(false), softline, ")")}
+	}
+
+	head := printed[:len(printed)-1]
+	last := args[len(args)-1]
+	var brk doc = ""
+	if willBreak(printed) {
+		brk = breakParent{}
+	}
+	flat := []doc{"(", head, last, ")"}
+	hugged := []doc{"(", head, &group{contents: last, brk: true}, ")"}
+	return []doc{callee, brk, &group{contents: flat, expanded: []doc{flat, hugged, allBroken}}}
+}
+
+// function returns a function or method declaration, whose parameters are one
+// per line when the signature doesn't fit. The return type is left out when
+// it's nil.
+func (p *printer) function(head string, params []doc, returns doc, body ...doc) doc {
+	var signature doc = "()"
+	if len(params) > 0 {
+		signature = []doc{"(", indent(softline, join([]doc{",", line}, params)), p.format.comma(false), softline, ")"}
+	}
+	if returns != nil {
+		signature = []doc{signature, ": ", returns}
+	}
+	return []doc{head, newGroup(signature), " ", block(body...)}
+}
+
+// block returns a block of statements, each on its own line
+func block(statements ...doc) doc {
+	if len(statements) == 0 {
+		return "{}"
+	}
+	return []doc{"{", indent(hardline, join(hardline, statements)), hardline, "}"}
+}
+
+// ifStatement returns an if statement, whose condition is on its own lines
+// when it doesn't fit
+func (p *printer) ifStatement(test doc, body ...doc) doc {
+	return []doc{"if (", newGroup(indent(softline, test), softline), ") ", block(body...)}
+}
+
+func (p *printer) forStatement(head string, body ...doc) doc {
+	return []doc{"for (", head, ") ", block(body...)}
+}
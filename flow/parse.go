@@ -0,0 +1,250 @@
+package flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// keywords maps the Flow keywords to the primitives of the model
+var keywords = map[string]string{
+	"string":  model.String,
+	"number":  model.Number,
+	"boolean": model.Boolean,
+	"null":    model.Null,
+	"void":    model.Void,
+	"mixed":   model.Unknown,
+	"any":     model.Any,
+	"empty":   model.Never,
+}
+
+// ParseType parses a Flow type, e.g. of a type mapping, into the type
+// expression of the model. Function types, typeof and other types the model
+// can't express are an error.
+func ParseType(s string) (*model.TypeExpr, error) {
+	p := &typeParser{tokens: tokenize(s)}
+	if p.tokens == nil {
+		return nil, fmt.Errorf("unsupported Flow type %q", s)
+	}
+	t := p.union()
+	if p.err || p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unsupported Flow type %q", s)
+	}
+	return t, nil
+}
+
+var tokenPattern = regexp.MustCompile(`^(\s+|\{\||\|\}|\.\.\.|"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|-?(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?|[A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*|[{}\[\]<>(),;:?|])`)
+
+// tokenize splits a Flow type into its tokens, nil if it has a token that
+// isn't supported
+func tokenize(s string) []string {
+	tokens := []string{}
+	for s != "" {
+		m := tokenPattern.FindString(s)
+		if m == "" {
+			return nil
+		}
+		if strings.TrimSpace(m) != "" {
+			tokens = append(tokens, m)
+		}
+		s = s[len(m):]
+	}
+	return tokens
+}
+
+type typeParser struct {
+	tokens []string
+	pos    int
+	err    bool
+}
+
+func (p *typeParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *typeParser) next() string {
+	t := p.peek()
+	if t == "" {
+		p.err = true
+	}
+	p.pos++
+	return t
+}
+
+func (p *typeParser) expect(token string) {
+	if p.next() != token {
+		p.err = true
+	}
+}
+
+func (p *typeParser) union() *model.TypeExpr {
+	if p.peek() == "|" {
+		p.next()
+	}
+	members := []*model.TypeExpr{p.prefix()}
+	for p.peek() == "|" && !p.err {
+		p.next()
+		members = append(members, p.prefix())
+	}
+	if len(members) == 1 {
+		return members[0]
+	}
+	var flattened []*model.TypeExpr
+	for _, m := range members {
+		if m != nil && m.Kind == model.TypeUnion {
+			flattened = append(flattened, m.Members...)
+		} else {
+			flattened = append(flattened, m)
+		}
+	}
+	return model.UnionOf(flattened...)
+}
+
+func (p *typeParser) prefix() *model.TypeExpr {
+	if p.peek() == "?" {
+		p.next()
+		t := p.prefix()
+		if t == nil {
+			return nil
+		}
+		return &model.TypeExpr{Kind: model.TypeNullable, Elem: t}
+	}
+	t := p.primary()
+	for p.peek() == "[" && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1] == "]" {
+		p.pos += 2
+		t = model.ArrayOf(t)
+	}
+	return t
+}
+
+func (p *typeParser) primary() *model.TypeExpr {
+	if p.err {
+		return nil
+	}
+	tok := p.next()
+	switch {
+	case p.err:
+		return nil
+	case tok == "(":
+		t := p.union()
+		p.expect(")")
+		return t
+	case tok == "{" || tok == "{|":
+		return p.object(tok == "{|")
+	case tok == "[":
+		tuple := model.TupleOf()
+		for p.peek() != "]" && !p.err {
+			tuple.Elems = append(tuple.Elems, p.union())
+			if p.peek() != "," {
+				break
+			}
+			p.next()
+		}
+		p.expect("]")
+		return tuple
+	case tok == "true" || tok == "false":
+		return model.Literal(tok == "true")
+	case strings.HasPrefix(tok, `"`) || strings.HasPrefix(tok, "'"):
+		s, ok := unquoteJS(tok)
+		if !ok {
+			p.err = true
+		}
+		return model.Literal(s)
+	case strings.ContainsAny(tok[:1], "-.0123456789"):
+		return model.Literal(json.Number(normalizeNumber(tok)))
+	case tok == "typeof":
+		p.err = true
+		return nil
+	case keywords[tok] != "" && p.peek() != "<":
+		return model.Primitive(keywords[tok])
+	case isIdentStart(tok):
+		t := model.Ref(tok)
+		if p.peek() == "<" {
+			p.next()
+			for !p.err {
+				t.Args = append(t.Args, p.union())
+				if p.peek() != "," {
+					break
+				}
+				p.next()
+			}
+			p.expect(">")
+		}
+		if tok == "Array" && len(t.Args) == 1 {
+			return model.ArrayOf(t.Args[0])
+		}
+		return t
+	}
+	p.err = true
+	return nil
+}
+
+func (p *typeParser) object(exact bool) *model.TypeExpr {
+	closing := "}"
+	if exact {
+		closing = "|}"
+	}
+	obj := &model.TypeExpr{Kind: model.TypeObject, Exact: exact}
+	for p.peek() != closing && !p.err {
+		field := &model.Field{}
+		switch tok := p.next(); {
+		case tok == "...":
+			field.Spread = true
+			field.Type = p.prefix()
+		case tok == "[":
+			if p.pos+1 < len(p.tokens) && p.tokens[p.pos+1] == ":" {
+				// The name of the key is only documentation
+				p.pos += 2
+			}
+			field.Key = p.union()
+			p.expect("]")
+			p.expect(":")
+			field.Type = p.union()
+		case isIdentStart(tok) && !strings.Contains(tok, "."):
+			field.Name = tok
+		case strings.HasPrefix(tok, `"`) || strings.HasPrefix(tok, "'"):
+			key, ok := unquoteJS(tok)
+			if !ok {
+				p.err = true
+			}
+			field.Name = key
+		default:
+			p.err = true
+		}
+		if !field.Spread && field.Key == nil {
+			if p.peek() == "?" {
+				p.next()
+				field.Optional = true
+			}
+			p.expect(":")
+			field.Type = p.union()
+			if t := field.Type; !field.Optional && t != nil && t.Kind == model.TypeNullable {
+				field.Type, field.Nullable = t.Elem, true
+			}
+		}
+		obj.Fields = append(obj.Fields, field)
+		if p.peek() != "," && p.peek() != ";" {
+			break
+		}
+		p.next()
+	}
+	p.expect(closing)
+
+	// An object with only an indexer is a map
+	if len(obj.Fields) == 1 && obj.Fields[0].Key != nil && !exact {
+		return model.MapOf(obj.Fields[0].Key, obj.Fields[0].Type)
+	}
+	return obj
+}
+
+func isIdentStart(tok string) bool {
+	r := rune(tok[0])
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
@@ -0,0 +1,61 @@
+package flow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// Flow pragmas, which opt a module into type checking
+const (
+	PragmaFlow       = "@flow"
+	PragmaFlowStrict = "@flow strict"
+)
+
+// Prologue is the comment at the top of the printed modules. The zero value
+// prints none.
+type Prologue struct {
+	// Pragma is the Flow pragma of the modules, PragmaFlow or PragmaFlowStrict,
+	// none if empty
+	Pragma string
+	// Generated adds an @generated banner naming the Go source of the module,
+	// which tools such as code review and linters recognise
+	Generated bool
+	// Command is the command regenerating the modules, given in the banner
+	Command string
+	// ESLintDisable are the rules of the eslint-disable comments of the
+	// modules, a comment per string, e.g. "flowtype/require-exact-type,
+	// no-unused-vars", or all the rules for an empty string
+	ESLintDisable []string
+}
+
+func (p Prologue) validate() error {
+	switch p.Pragma {
+	case "", PragmaFlow, PragmaFlowStrict:
+		return nil
+	}
+	return fmt.Errorf("unsupported Flow pragma %q, expected %s or %s", p.Pragma, PragmaFlow, PragmaFlowStrict)
+}
+
+// lines returns the lines of the prologue of the file
+func (p Prologue) lines(f *model.File) []string {
+	var lines []string
+	if p.Pragma != "" {
+		lines = append(lines, "// "+p.Pragma)
+	}
+	if p.Generated {
+		banner := "// @generated by go2flow"
+		if f.Source != "" {
+			banner += " from " + f.Source
+		}
+		lines = append(lines, banner+", do not edit")
+		if p.Command != "" {
+			lines = append(lines, "// Regenerate with: "+p.Command)
+		}
+	}
+	for _, rules := range p.ESLintDisable {
+		lines = append(lines, strings.TrimRight("/* eslint-disable "+rules, " ")+" */")
+	}
+	return lines
+}
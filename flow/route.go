@@ -0,0 +1,89 @@
+package flow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// reservedParams are the names that a path parameter of a client function can't
+// take: the function's other parameters, the globals its body calls, and the
+// reserved words of JS
+var reservedParams = map[string]bool{
+	"query": true, "body": true, "options": true,
+	"request": true, "encodeURI": true, "encodeURIComponent": true,
+
+	"arguments": true, "await": true, "break": true, "case": true, "catch": true,
+	"class": true, "const": true, "continue": true, "debugger": true, "default": true,
+	"delete": true, "do": true, "else": true, "enum": true, "eval": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true,
+	"function": true, "if": true, "implements": true, "import": true, "in": true,
+	"instanceof": true, "interface": true, "let": true, "new": true, "null": true,
+	"package": true, "private": true, "protected": true, "public": true,
+	"return": true, "static": true, "super": true, "switch": true, "this": true,
+	"throw": true, "true": true, "try": true, "typeof": true, "var": true,
+	"void": true, "while": true, "with": true, "yield": true,
+}
+
+// paramName returns the JS identifier of a path parameter, in camel case, e.g.
+// productId for product-id, renamed with a Param suffix when it would clash with
+// a reserved name or one that's taken
+func paramName(param string, taken map[string]bool) string {
+	words := strings.FieldsFunc(param, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '$'
+	})
+	var name strings.Builder
+	for i, w := range words {
+		if i > 0 {
+			runes := []rune(w)
+			runes[0] = unicode.ToUpper(runes[0])
+			w = string(runes)
+		}
+		name.WriteString(w)
+	}
+
+	base := name.String()
+	if base == "" || unicode.IsDigit([]rune(base)[0]) {
+		base = "param" + base
+	}
+	id := base
+	for i := 1; reservedParams[id] || taken[id]; i++ {
+		id = base + "Param"
+		if i > 1 {
+			id += strconv.Itoa(i)
+		}
+	}
+	taken[id] = true
+	return id
+}
+
+// routePath returns the template literal of a route's path and the names of
+// its path parameters, rebuilt segment by segment with each parameter encoded
+func routePath(r *model.Route) (path string, params []string) {
+	taken := map[string]bool{}
+	segments := strings.Split(r.Path, "/")
+	for i, segment := range segments {
+		param, ok := model.PathParam(segment)
+		if !ok {
+			segments[i] = escapeTemplate(segment)
+			continue
+		}
+		name := paramName(param, taken)
+		params = append(params, name)
+		encode := "encodeURIComponent"
+		if strings.HasSuffix(segment, "...}") {
+			// A wildcard matches the rest of the path, slashes included
+			encode = "encodeURI"
+		}
+		segments[i] = fmt.Sprintf("${%s(%s)}", encode, name)
+	}
+	return "`" + strings.Join(segments, "/") + "`", params
+}
+
+// escapeTemplate escapes the text of a template literal
+func escapeTemplate(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${").Replace(s)
+}
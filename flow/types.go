@@ -0,0 +1,334 @@
+package flow
+
+import (
+	"regexp"
+
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// The type expressions of the model are converted to the Flow syntax below to
+// be laid out
+
+type flowType interface{}
+
+// namedType is a keyword such as string, or a generic type with its type
+// arguments if any
+type namedType struct {
+	name string
+	args []flowType
+}
+
+type typeofType struct{ name string }
+
+// literalType is a string, number or boolean literal, or null when its value is
+// nil
+type literalType struct{ value interface{} }
+
+type unionType struct{ members []flowType }
+
+type nullableType struct{ t flowType }
+
+type tupleType struct{ elems []flowType }
+
+type objectType struct {
+	exact bool
+	props []objectProp
+}
+
+// objectProp is a property, an indexer with a key type, or a spread type
+type objectProp struct {
+	key      string
+	optional bool
+	indexer  flowType
+	spread   bool
+	value    flowType
+	// comment are the lines of the JSDoc comment printed above the property
+	comment []string
+}
+
+// flowKeywords are the Flow keywords of the primitives of the model that have
+// another name
+var flowKeywords = map[string]string{
+	model.Unknown: "mixed",
+	model.Never:   "empty",
+}
+
+// toFlow converts a type expression of the model to its Flow syntax
+func toFlow(t *model.TypeExpr) flowType {
+	if t == nil {
+		return namedType{name: "void"}
+	}
+	switch t.Kind {
+	case model.TypePrimitive:
+		if t.Name == model.Null {
+			return literalType{}
+		}
+		if keyword, ok := flowKeywords[t.Name]; ok {
+			return namedType{name: keyword}
+		}
+		return namedType{name: t.Name}
+	case model.TypeRef:
+		n := namedType{name: t.Name}
+		for _, a := range t.Args {
+			n.args = append(n.args, toFlow(a))
+		}
+		return n
+	case model.TypeLiteral:
+		return literalType{value: t.Value}
+	case model.TypeArray:
+		return namedType{name: "Array", args: []flowType{toFlow(t.Elem)}}
+	case model.TypeTuple:
+		tuple := tupleType{}
+		for _, e := range t.Elems {
+			tuple.elems = append(tuple.elems, toFlow(e))
+		}
+		return tuple
+	case model.TypeMap:
+		return objectType{props: []objectProp{{indexer: toFlow(t.Key), value: toFlow(t.Elem)}}}
+	case model.TypeObject:
+		obj := objectType{exact: t.Exact}
+		for _, f := range t.Fields {
+			obj.props = append(obj.props, fieldProp(f))
+		}
+		return obj
+	case model.TypeUnion:
+		u := unionType{}
+		for _, m := range t.Members {
+			if m := toFlow(m); isUnion(m) {
+				u.members = append(u.members, m.(unionType).members...)
+			} else {
+				u.members = append(u.members, m)
+			}
+		}
+		return u
+	case model.TypeNullable:
+		return nullableType{t: toFlow(t.Elem)}
+	}
+	return namedType{name: "mixed"}
+}
+
+func isUnion(t flowType) bool {
+	_, ok := t.(unionType)
+	return ok
+}
+
+// fieldProp returns the property of a field of a struct or an object type
+func fieldProp(f *model.Field) objectProp {
+	prop := objectProp{value: toFlow(f.Type)}
+	switch {
+	case f.Key != nil:
+		prop.indexer = toFlow(f.Key)
+	case f.Spread:
+		prop.spread = true
+	case f.Optional:
+		// https://flow.org/en/docs/types/primitives/#toc-optional-object-properties
+		prop.key, prop.optional = f.Name, true
+	case f.Nullable:
+		// https://flow.org/en/docs/types/primitives/#toc-maybe-types
+		prop.key, prop.value = f.Name, toFlow(model.NullableOf(f.Type))
+	default:
+		prop.key = f.Name
+	}
+	prop.comment = constraintTags(f.Constraints)
+	return prop
+}
+
+// TypeString returns the Flow type of a type expression on a single line, e.g.
+// for templates and reports
+func TypeString(t *model.TypeExpr) string {
+	p := &printer{docs: &docPrinter{width: 1 << 30, tabWidth: 2}}
+	p.format, _ = Format{}.withDefaults()
+	return p.docs.print(p.flowTypeDoc(toFlow(t), inDeclaration))
+}
+
+var es5Identifier = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+
+// shouldHug reports whether a type is kept on the line of the type arguments or
+// the union it's in: a keyword, a generic type without arguments, an object
+// type, or a union of an object type with null or void
+func shouldHug(t flowType) bool {
+	switch t := t.(type) {
+	case namedType:
+		return t.args == nil
+	case objectType:
+		return true
+	case unionType:
+		objects, voids := 0, 0
+		for _, m := range t.members {
+			if _, ok := m.(objectType); ok {
+				objects++
+			}
+			if n, ok := m.(namedType); ok && n.name == "void" && n.args == nil {
+				voids++
+			}
+			if l, ok := m.(literalType); ok && l.value == nil {
+				voids++
+			}
+		}
+		return objects > 0 && len(t.members)-1 == voids
+	}
+	return false
+}
+
+// unionParent is where a union is, which decides how it's indented
+type unionParent int
+
+const (
+	// inDeclaration is a union that is the type of a declaration or property,
+	// indented under it when broken
+	inDeclaration unionParent = iota
+	// inTypeArgs is a union that is a type argument, or the only element of
+	// a tuple
+	inTypeArgs
+	// inTuple is a union that is one of several elements of a tuple
+	inTuple
+	// inParens is a union that is parenthesized, e.g. the type of a nullable
+	inParens
+)
+
+// typeDoc returns the doc of the Flow type of a type expression
+func (p *printer) typeDoc(t *model.TypeExpr) doc {
+	return p.flowTypeDoc(toFlow(t), inDeclaration)
+}
+
+func (p *printer) flowTypeDoc(t flowType, parent unionParent) doc {
+	switch t := t.(type) {
+	case namedType:
+		if t.args == nil {
+			return t.name
+		}
+		return []doc{t.name, p.typeArgs(t.args)}
+	case typeofType:
+		return "typeof " + t.name
+	case literalType:
+		return p.format.literalText(t.value)
+	case nullableType:
+		return []doc{"?", p.wrappedDoc(t.t)}
+	case tupleType:
+		if len(t.elems) == 0 {
+			return "[]"
+		}
+		elemParent := inTypeArgs
+		if len(t.elems) > 1 {
+			elemParent = inTuple
+		}
+		elems := make([]doc, len(t.elems))
+		for i, e := range t.elems {
+			elems[i] = p.flowTypeDoc(e, elemParent)
+		}
+		return newGroup("[", indent(softline, join([]doc{",", line}, elems)), p.format.comma(false), softline, "]")
+	case objectType:
+		return p.objectTypeDoc(t, false)
+	case unionType:
+		return p.unionDoc(t, parent)
+	}
+	return ""
+}
+
+// wrappedDoc returns the doc of the type of a nullable, which is parenthesized
+// when it's a union
+func (p *printer) wrappedDoc(t flowType) doc {
+	if isUnion(t) {
+		return []doc{"(", p.flowTypeDoc(t, inParens), ")"}
+	}
+	return p.flowTypeDoc(t, inDeclaration)
+}
+
+// unionDoc lays out a union on one line, or else with a member per line
+// preceded by |
+func (p *printer) unionDoc(t unionType, parent unionParent) doc {
+	hug := shouldHug(t)
+	members := make([]doc, len(t.members))
+	for i, m := range t.members {
+		members[i] = p.flowTypeDoc(m, inDeclaration)
+		if !hug {
+			members[i] = align(2, members[i])
+		}
+	}
+	if hug {
+		return join(" | ", members)
+	}
+
+	shouldIndent := parent == inDeclaration || parent == inParens
+	var start doc = ""
+	if shouldIndent {
+		start = line
+	}
+	code := []doc{ifBreak{breakContents: []doc{start, "| "}}, join([]doc{line, "| "}, members)}
+	switch parent {
+	case inParens:
+		return newGroup(indent(code), softline)
+	case inTuple:
+		return newGroup(indent(ifBreak{breakContents: []doc{"(", softline}}, code), softline, ifBreak{breakContents: ")"})
+	case inDeclaration:
+		return newGroup(indent(code))
+	}
+	return newGroup(code)
+}
+
+// typeArgs returns the doc of the type arguments of a generic type
+func (p *printer) typeArgs(args []flowType) doc {
+	if len(args) == 0 {
+		return "<>"
+	}
+	if len(args) == 1 {
+		if _, ok := args[0].(nullableType); ok || shouldHug(args[0]) {
+			return []doc{"<", p.flowTypeDoc(args[0], inTypeArgs), ">"}
+		}
+	}
+	docs := make([]doc, len(args))
+	for i, a := range args {
+		docs[i] = p.flowTypeDoc(a, inTypeArgs)
+	}
+	return newGroup("<", indent(softline, join([]doc{",", line}, docs)), p.format.comma(false), softline, ">")
+}
+
+// objectTypeDoc lays out an object type, broken over several lines when brk is
+// set
+func (p *printer) objectTypeDoc(t objectType, brk bool) doc {
+	open, close := "{", "}"
+	if t.exact {
+		open, close = "{|", "|}"
+	}
+	if len(t.props) == 0 {
+		return open + close
+	}
+	props := make([]doc, len(t.props))
+	for i, prop := range t.props {
+		props[i] = p.propDoc(prop)
+	}
+	g := newGroup(open, indent(line, join([]doc{",", line}, props)), p.format.comma(true), line, close)
+	g.brk = brk
+	return g
+}
+
+func (p *printer) propDoc(prop objectProp) doc {
+	if len(prop.comment) > 0 {
+		lines := []doc{"/**"}
+		for _, l := range prop.comment {
+			lines = append(lines, " * "+l)
+		}
+		lines = append(lines, " */")
+		prop.comment = nil
+		return []doc{join(hardline, lines), hardline, p.propDoc(prop)}
+	}
+	switch {
+	case prop.spread:
+		return []doc{"...", p.flowTypeDoc(prop.value, inDeclaration)}
+	case prop.indexer != nil:
+		return []doc{"[", p.flowTypeDoc(prop.indexer, inDeclaration), "]: ", p.flowTypeDoc(prop.value, inDeclaration)}
+	}
+	optional := ""
+	if prop.optional {
+		optional = "?"
+	}
+	return []doc{p.propertyKey(prop.key), optional, ": ", p.flowTypeDoc(prop.value, inDeclaration)}
+}
+
+// propertyKey returns the key of a property, quoted unless it's an identifier
+func (p *printer) propertyKey(key string) string {
+	if es5Identifier.MatchString(key) {
+		return key
+	}
+	return p.format.quote(key)
+}
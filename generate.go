@@ -0,0 +1,428 @@
+// Package go2flow generates Flow type definitions from the type definitions in
+// Go source files. It is the library behind the go2flow command, for use from
+// build tools and go:generate wrappers.
+package go2flow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kristiehoward/go2flow/flow"
+	"github.com/kristiehoward/go2flow/handlers"
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/kristiehoward/go2flow/typeutils"
+)
+
+// LanguageFlow is the default target language, whose emitter is registered by
+// go2flow
+const LanguageFlow = "flow"
+
+// LanguageJSONSchema prints the type definitions of each module as a JSON Schema
+// document, with the bounds of the fields' validation rules
+const LanguageJSONSchema = "jsonschema"
+
+// Config describes which Go files to consume and how to translate them
+type Config struct {
+	// Patterns are the .go files, directories containing .go files, or glob
+	// patterns matching .go files to consume
+	Patterns []string
+	// Language is the target language of the generated definitions, the name
+	// of a registered Emitter. Defaults to LanguageFlow.
+	Language string
+	// Format is the layout of the Flow modules, Prettier's default layout
+	// unless set
+	Format flow.Format
+	// Prologue is the comment at the top of the Flow modules: the Flow pragma,
+	// the @generated banner and eslint-disable comments
+	Prologue flow.Prologue
+	// TypeMappings maps the string representation of a Go type (e.g.
+	// `time.Duration`) to the type to generate for it, in addition to and
+	// overriding the built-in mappings
+	TypeMappings map[string]string
+	// Packs are the names of the curated type mapping packs to add, see
+	// typeutils.Packs. TypeMappings override their mappings.
+	Packs []string
+	// TagKeys are the struct tag keys, in order of precedence, that decide the
+	// property names and optionality of struct fields, e.g. json, yaml, bson or
+	// mapstructure. Defaults to json.
+	TagKeys []string
+	// JSONv2 follows the semantics of encoding/json/v2 for json tags: explicit
+	// `inline` and `unknown` fields, `format:` options and v2's omitempty
+	JSONv2 bool
+	// Opaque emits the types defined from a string, number or boolean, e.g.
+	// `type ProductID string`, as Flow opaque types with helpers to create
+	// them. Types can also opt in with a //go2flow:opaque directive, or out
+	// with //go2flow:opaque false.
+	Opaque bool
+	// EnumObjects emits frozen objects and arrays of the values of enum types,
+	// i.e. types with constants, labelled by the constants' doc comments, so
+	// the values are available at runtime. Enum types are otherwise unions of
+	// their values.
+	EnumObjects bool
+	// ExportConsts emits the exported package level constants that don't
+	// belong to an enum type as JS constants, e.g. `export const MaxPageSize:
+	// 100 = 100;`. Constants can also opt in with a //go2flow:export directive,
+	// or out with //go2flow:export false.
+	ExportConsts bool
+	// RPCPrefix is the path prefix of the methods of services, see
+	// handlers.Service. Defaults to handlers.DefaultRPCPrefix, the Twirp
+	// convention, use / for Connect.
+	RPCPrefix string
+	// MaxTupleLength is the longest fixed-size array, e.g. `[3]float64`,
+	// translated to a tuple; longer arrays are translated to Array<T>. Defaults
+	// to typeutils.DefaultMaxTupleLength, a negative length disables tuples.
+	MaxTupleLength int
+	// ImportModules maps the import paths of Go packages to the Flow modules
+	// that define their types, e.g. k8s.io/apimachinery/pkg/apis/meta/v1 to
+	// @acme/k8s-types/meta
+	ImportModules map[string]string
+	// GenerateImports generates the Flow definitions of the other imported
+	// packages from their source in the vendor directory, the GOPATH or the
+	// module cache
+	GenerateImports bool
+	// ImportsDir is where the definitions of imported packages are generated.
+	// Defaults to DefaultImportsDir.
+	ImportsDir string
+}
+
+// Diagnostic describes a Go construct that could not be translated, or a
+// warning, at its file:line:col position
+type Diagnostic = typeutils.Diagnostic
+
+// Errors returns the diagnostics of the constructs that could not be
+// translated, leaving out the warnings
+func Errors(diagnostics []Diagnostic) []Diagnostic {
+	return typeutils.Errors(diagnostics)
+}
+
+// Generate translates the type definitions of the Go files matched by the
+// config's patterns. It returns the generated definitions keyed by output file
+// name, which for Flow is the input file name with its .go extension replaced
+// by .js, and by the emitter's FileName for the other languages,
+// along with the client modules of the files declaring routes or services (see
+// handlers.Route, handlers.Service and ClientName), and a diagnostic for each
+// construct that could not be translated.
+func Generate(ctx context.Context, cfg Config) (map[string][]byte, []Diagnostic, error) {
+	if _, err := lookupEmitter(cfg.Language); err != nil {
+		return nil, nil, err
+	}
+	schema, diagnostics, err := Analyze(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	outputs, err := Render(schema, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return outputs, diagnostics, nil
+}
+
+// Analyze returns the model of the modules that Generate prints, and a
+// diagnostic for each construct that could not be translated. The model doesn't
+// depend on the config's Language.
+func Analyze(ctx context.Context, cfg Config) (*model.Schema, []Diagnostic, error) {
+	g, err := generate(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g.schema, g.types.Diagnostics, nil
+}
+
+// Render prints the modules of the schema with the emitter that the factory
+// registered for the config's Language returns for the config, keyed by output
+// file name. The Flow emitter lays the modules out in the config's Format after
+// its Prologue.
+func Render(schema *model.Schema, cfg Config) (map[string][]byte, error) {
+	factory, err := lookupEmitter(cfg.Language)
+	if err != nil {
+		return nil, err
+	}
+	return Emit(schema, factory(cfg))
+}
+
+// Emit prints the modules of the schema with the emitter, keyed by the file
+// names the emitter gives them
+func Emit(schema *model.Schema, e Emitter) (map[string][]byte, error) {
+	if schema.Version > model.Version {
+		return nil, fmt.Errorf("the model is version %d, which is newer than version %d of go2flow", schema.Version, model.Version)
+	}
+	outputs := make(map[string][]byte, len(schema.Files))
+	for _, f := range schema.Files {
+		var buf bytes.Buffer
+		if err := e.Emit(&buf, f); err != nil {
+			return nil, err
+		}
+		outputs[e.FileName(f)] = buf.Bytes()
+	}
+	return outputs, nil
+}
+
+func generate(ctx context.Context, cfg Config) (*generator, error) {
+	files, err := resolvePatterns(cfg.Patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := typeutils.PackMappings(cfg.Packs)
+	if err != nil {
+		return nil, err
+	}
+	for goType, flowType := range cfg.TypeMappings {
+		mappings[goType] = flowType
+	}
+	typeMap := make(map[string]*model.TypeExpr, len(mappings))
+	for goType, flowType := range mappings {
+		t, err := flow.ParseType(flowType)
+		if err != nil {
+			return nil, fmt.Errorf("type mapping of %s: %v", goType, err)
+		}
+		typeMap[goType] = t
+	}
+
+	types := typeutils.NewTranslator(typeMap)
+	types.Fset = token.NewFileSet()
+	if cfg.MaxTupleLength != 0 {
+		types.MaxTupleLength = cfg.MaxTupleLength
+	}
+	packages, err := parsePackages(types.Fset, files)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		cfg:        cfg,
+		types:      types,
+		schema:     &model.Schema{Version: model.Version},
+		importDirs: map[string]string{},
+	}
+	types.ResolveImport = g.resolveImport
+	for _, pkg := range packages {
+		types.Pkg = typeutils.NewPackage(append(pkg.astFiles, pkg.companions...)...)
+		for i, file := range pkg.files {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			types.BeginFile(pkg.astFiles[i])
+			g.dir = filepath.Dir(file)
+			f := generateFile(pkg.astFiles[i], types, cfg)
+			f.Name = OutputName(file)
+			f.Source = packageSource(file, pkg.astFiles[i])
+			f.Imports = g.imports(f.Name, types.ImportedTypes())
+			linkImports(f)
+			g.schema.Files = append(g.schema.Files, f)
+			routes := handlers.Routes(pkg.astFiles[i], types)
+			services := handlers.Services(pkg.astFiles[i], types, cfg.RPCPrefix)
+			if len(routes) > 0 || len(services) > 0 {
+				g.generateClient(file, pkg.astFiles[i], routes, services)
+			}
+		}
+	}
+
+	// Generate the imported packages, which may import further packages
+	for len(g.pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pkgPath := g.pending[0]
+		g.pending = g.pending[1:]
+		if err := g.generateImport(pkgPath); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// generator holds the state of one Generate call
+type generator struct {
+	cfg   Config
+	types *typeutils.Translator
+	// schema receives the generated modules
+	schema *model.Schema
+	// dir is the directory of the file being translated
+	dir string
+	// importDirs maps the imported packages to generate to their source
+	// directory, empty if the source isn't available
+	importDirs map[string]string
+	// pending are the imported packages still to generate
+	pending []string
+}
+
+// linkImports sets the module of the references to the types that the file
+// imports
+func linkImports(f *model.File) {
+	modules := map[string]string{}
+	for _, imp := range f.Imports {
+		for _, name := range imp.Names {
+			if name.As != "" {
+				modules[name.As] = imp.Module
+			} else {
+				modules[name.Name] = imp.Module
+			}
+		}
+	}
+	f.Walk(func(t *model.TypeExpr) {
+		if module, ok := modules[t.Name]; ok && t.Kind == model.TypeRef {
+			t.Module = module
+		}
+	})
+}
+
+// packageSource returns the source of the modules generated for a .go file: the
+// name and directory of its package
+func packageSource(file string, astFile *ast.File) string {
+	return fmt.Sprintf("%s (%s)", astFile.Name.Name, filepath.Dir(file))
+}
+
+// OutputName returns the name of the file generated for a Go source file
+func OutputName(file string) string {
+	return strings.TrimSuffix(file, ".go") + ".js"
+}
+
+// sourcePackage holds the parsed files of one package, i.e. of one directory
+type sourcePackage struct {
+	files    []string
+	astFiles []*ast.File
+	// companions are the package's stringer generated files that weren't
+	// requested, which are indexed but not translated
+	companions []*ast.File
+}
+
+// parsePackages parses the files, grouping them into packages by directory so
+// that a type definition can depend on declarations in the package's other files
+func parsePackages(fset *token.FileSet, files []string) ([]*sourcePackage, error) {
+	var packages []*sourcePackage
+	byDir := map[string]*sourcePackage{}
+	for _, file := range files {
+		// Parse the src file's information into the astNode, including the comments
+		astNode, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		dir := filepath.Dir(file)
+		pkg, ok := byDir[dir]
+		if !ok {
+			pkg = &sourcePackage{}
+			byDir[dir] = pkg
+			packages = append(packages, pkg)
+		}
+		pkg.files = append(pkg.files, file)
+		pkg.astFiles = append(pkg.astFiles, astNode)
+	}
+
+	// The String methods of enums are often generated by stringer in companion
+	// files, which name the values of text-encoded enums
+	for dir, pkg := range byDir {
+		for _, file := range typeutils.StringerFiles(dir) {
+			if containsString(pkg.files, file) {
+				continue
+			}
+			astNode, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			pkg.companions = append(pkg.companions, astNode)
+		}
+	}
+	return packages, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// generateFile returns the model of the module generated for a file
+func generateFile(astNode *ast.File, types *typeutils.Translator, cfg Config) *model.File {
+	h := handlers.NewHandler(types)
+	h.TagKeys = cfg.TagKeys
+	h.JSONv2 = cfg.JSONv2
+	h.Opaque = cfg.Opaque
+	h.EnumObjects = cfg.EnumObjects
+	h.ExportConsts = cfg.ExportConsts
+	// Inspect the AST, handling only type definitions and package level
+	// constants
+	for _, decl := range astNode.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.CONST {
+			h.HandleConstDecl(*d)
+			continue
+		}
+		ast.Inspect(decl, func(node ast.Node) bool {
+			if ts, ok := node.(*ast.TypeSpec); ok {
+				h.HandleTypeDef(*ts)
+			}
+			return true
+		})
+	}
+	return h.File
+}
+
+// resolvePatterns expands the patterns into a sorted list of .go files
+func resolvePatterns(patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no .go files to consume")
+	}
+
+	seen := map[string]bool{}
+	var files []string
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, pattern := range patterns {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			matches, err = filepath.Glob(pattern)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+
+			// Handle directory
+			entries, err := ioutil.ReadDir(match)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				name := entry.Name()
+				if entry.IsDir() || !isSourceFile(name) {
+					continue
+				}
+				add(filepath.Join(match, name))
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// isSourceFile reports whether name is a non-test .go file
+func isSourceFile(name string) bool {
+	return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
+}
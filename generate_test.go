@@ -0,0 +1,582 @@
+package go2flow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kristiehoward/go2flow/flow"
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSource writes src to name in a new temporary directory and returns the
+// directory
+func writeSource(t *testing.T, name, src string) string {
+	dir, err := ioutil.TempDir("", "go2flow")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644))
+	return dir
+}
+
+func TestGenerate(t *testing.T) {
+	dir := writeSource(t, "types.go", `package schema
+
+import "time"
+
+type Product struct {
+	ID      string        `+"`json:\"id\"`"+`
+	Timeout time.Duration `+"`json:\"timeout,omitempty\"`"+`
+}
+`)
+
+	outputs, diagnostics, err := Generate(context.Background(), Config{
+		Patterns:     []string{dir},
+		TypeMappings: map[string]string{"time.Duration": "number"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, diagnostics)
+	assert.Equal(t, map[string][]byte{
+		filepath.Join(dir, "types.js"): []byte("export type Product = {\n  id: string,\n  timeout?: number,\n};\n"),
+	}, outputs)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := writeSource(t, "types.go", "package schema\n")
+
+	_, _, err := Generate(context.Background(), Config{Patterns: []string{dir}, Language: "typescript"})
+	assert.Error(t, err, "unsupported language")
+
+	_, _, err = Generate(context.Background(), Config{})
+	assert.Error(t, err, "no patterns")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = Generate(ctx, Config{Patterns: []string{dir}})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestGenerateDiagnostics(t *testing.T) {
+	dir := writeSource(t, "types.go", `package schema
+
+type Product struct {
+	Price complex64 `+"`json:\"price\"`"+`
+	Extra chan int `+"`json:\"extra\"`"+`
+}
+`)
+
+	_, diagnostics, err := Generate(context.Background(), Config{Patterns: []string{dir}})
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 2)
+	assert.Equal(t, filepath.Join(dir, "types.go")+":4:8: no Flow type for complex64", diagnostics[0].String())
+	assert.Equal(t, filepath.Join(dir, "types.go")+":5:8: unsupported type chan int", diagnostics[1].String())
+	assert.Len(t, Errors(diagnostics), 2)
+
+	// Warnings don't fail --strict, which only counts the errors
+	dir = writeSource(t, "events.go", `package events
+
+type Event interface{ isEvent() }
+
+type Created struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+type replayed struct{}
+
+func (Created) isEvent()  {}
+func (replayed) isEvent() {}
+`)
+	_, diagnostics, err = Generate(context.Background(), Config{Patterns: []string{dir}})
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, filepath.Join(dir, "events.go")+":3:6: warning: unexported replayed implements sealed interface Event and is left out of its union", diagnostics[0].String())
+	assert.Empty(t, Errors(diagnostics))
+}
+
+func TestGenerateImports(t *testing.T) {
+	dir := writeSource(t, "types.go", `package schema
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+type ObjectMeta struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+type Pod struct {
+	Meta    ObjectMeta        `+"`json:\"meta\"`"+`
+	Created metav1.Time       `+"`json:\"created\"`"+`
+	Owner   metav1.ObjectMeta `+"`json:\"owner\"`"+`
+}
+`)
+	vendored := filepath.Join(dir, "vendor", "k8s.io", "apimachinery", "pkg", "apis", "meta", "v1")
+	require.NoError(t, os.MkdirAll(vendored, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(vendored, "types.go"), []byte(`package v1
+
+type Time struct{}
+
+type ObjectMeta struct {
+	Namespace string `+"`json:\"namespace\"`"+`
+}
+`), 0644))
+
+	outputs, diagnostics, err := Generate(context.Background(), Config{
+		Patterns:        []string{filepath.Join(dir, "types.go")},
+		GenerateImports: true,
+		ImportsDir:      filepath.Join(dir, "imports"),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, diagnostics)
+	assert.Equal(t, `import type {
+  ObjectMeta as Metav1ObjectMeta,
+  Time,
+} from "./imports/k8s.io/apimachinery/pkg/apis/meta/v1";
+
+export type ObjectMeta = {
+  name: string,
+};
+
+export type Pod = {
+  meta: ObjectMeta,
+  created: Time,
+  owner: Metav1ObjectMeta,
+};
+`, string(outputs[filepath.Join(dir, "types.js")]))
+	assert.Equal(t, `export type Time = {};
+
+export type ObjectMeta = {
+  namespace: string,
+};
+`, string(outputs[filepath.Join(dir, "imports", "k8s.io", "apimachinery", "pkg", "apis", "meta", "v1.js")]))
+
+	outputs, diagnostics, err = Generate(context.Background(), Config{
+		Patterns:      []string{filepath.Join(dir, "types.go")},
+		ImportModules: map[string]string{"k8s.io/apimachinery/pkg/apis/meta/v1": "@acme/k8s-types/meta"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, diagnostics)
+	assert.Len(t, outputs, 1)
+	assert.Contains(t, string(outputs[filepath.Join(dir, "types.js")]),
+		"import type {\n  ObjectMeta as Metav1ObjectMeta,\n  Time,\n} from \"@acme/k8s-types/meta\";\n")
+
+	// The model references the imported types along with their module
+	schema, _, err := Analyze(context.Background(), Config{
+		Patterns:      []string{filepath.Join(dir, "types.go")},
+		ImportModules: map[string]string{"k8s.io/apimachinery/pkg/apis/meta/v1": "@acme/k8s-types/meta"},
+	})
+	require.NoError(t, err)
+	owner, ok := schema.Files[0].Types()[1].Field("owner")
+	require.True(t, ok)
+	assert.Equal(t, &model.TypeExpr{Kind: model.TypeRef, Name: "Metav1ObjectMeta", Module: "@acme/k8s-types/meta"}, owner.Type)
+}
+
+func TestGeneratePacks(t *testing.T) {
+	dir := writeSource(t, "types.go", `package schema
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type Job struct {
+	ID      uuid.UUID          `+"`json:\"id\"`"+`
+	Note    sql.NullString     `+"`json:\"note\"`"+`
+	Port    *intstr.IntOrString `+"`json:\"port\"`"+`
+	Timeout time.Duration      `+"`json:\"timeout\"`"+`
+}
+`)
+
+	outputs, diagnostics, err := Generate(context.Background(), Config{
+		Patterns: []string{dir},
+		Packs:    []string{"k8s", "sql", "uuid", "time"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, diagnostics)
+	assert.Equal(t, `export type Job = {
+  id: string,
+  note: ?string,
+  port: ?(number | string),
+  timeout: number,
+};
+`, string(outputs[filepath.Join(dir, "types.js")]))
+
+	_, _, err = Generate(context.Background(), Config{Patterns: []string{dir}, Packs: []string{"nope"}})
+	assert.Error(t, err)
+	_, _, err = Generate(context.Background(), Config{Patterns: []string{dir}, TypeMappings: map[string]string{"time.Duration": "(number) => void"}})
+	assert.Error(t, err, "unsupported Flow type")
+}
+
+func TestGenerateClient(t *testing.T) {
+	dir := writeSource(t, "types.go", `package api
+
+type Product struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+type UpdateProductRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+type ListProductsRequest struct {
+	Page int `+"`json:\"page\"`"+`
+}
+`)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "handlers.go"), []byte(`package api
+
+import "net/http"
+
+//go2flow:route GET /products ListProductsRequest -> []Product
+func ListProducts(w http.ResponseWriter, r *http.Request) {}
+
+// handleUpdateProduct updates a product
+//go2flow:route PUT /products/{id} UpdateProductRequest -> Product
+func handleUpdateProduct(w http.ResponseWriter, r *http.Request) {}
+
+//go2flow:route DELETE /products/:id
+func DeleteProduct(w http.ResponseWriter, r *http.Request) {}
+
+//go2flow:route FETCH /products
+func FetchProducts(w http.ResponseWriter, r *http.Request) {}
+`), 0644))
+
+	outputs, diagnostics, err := Generate(context.Background(), Config{Patterns: []string{dir}})
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "invalid route for FetchProducts: unknown HTTP method FETCH", diagnostics[0].Message)
+	assert.Empty(t, outputs[filepath.Join(dir, "handlers.js")])
+	assert.NotContains(t, outputs, filepath.Join(dir, "types_client.js"))
+
+	client := string(outputs[filepath.Join(dir, "handlers_client.js")])
+	assert.True(t, strings.HasPrefix(client, "import type {\n  ListProductsRequest,\n  Product,\n  UpdateProductRequest,\n} from \"./types\";\n\n"))
+	assert.Contains(t, client, "async function request<T>(")
+	assert.Contains(t, client, `export async function listProducts(
+  query: ListProductsRequest,
+  options: RequestOptions = {},
+): Promise<Array<Product>> {
+  return request("GET", `+"`/products`"+`, query, undefined, options);
+}
+
+export async function updateProduct(
+  id: string,
+  body: UpdateProductRequest,
+  options: RequestOptions = {},
+): Promise<Product> {
+  return request(
+    "PUT",
+    `+"`/products/${encodeURIComponent(id)}`"+`,
+    undefined,
+    body,
+    options,
+  );
+}
+
+export async function deleteProduct(
+  id: string,
+  options: RequestOptions = {},
+): Promise<void> {
+  return request(
+    "DELETE",
+    `+"`/products/${encodeURIComponent(id)}`"+`,
+    undefined,
+    undefined,
+    options,
+  );
+}
+`)
+}
+
+func TestGenerateService(t *testing.T) {
+	dir := writeSource(t, "service.go", `package catalog
+
+import "context"
+
+type GetProductRequest struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+type Product struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+//go2flow:service acme.catalog.v1.Catalog
+type Catalog interface {
+	GetProduct(ctx context.Context, req *GetProductRequest) (*Product, error)
+	ListProducts(context.Context, *GetProductRequest) ([]Product, error)
+	Close() error
+}
+
+//go2flow:service
+type Inventory interface {
+	Count(context.Context, *GetProductRequest) (*Product, error)
+}
+
+const InventoryPathPrefix = "/rpc/acme.Inventory/"
+`)
+
+	outputs, diagnostics, err := Generate(context.Background(), Config{Patterns: []string{dir}})
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "method Close of service Catalog doesn't have the signature (context.Context, *Request) (*Response, error)", diagnostics[0].Message)
+	assert.NotContains(t, string(outputs[filepath.Join(dir, "service.js")]), "Catalog")
+
+	client := string(outputs[filepath.Join(dir, "service_client.js")])
+	assert.True(t, strings.HasPrefix(client, "import type { GetProductRequest, Product } from \"./service\";\n\n"))
+	assert.Contains(t, client, `export const CatalogPaths = Object.freeze({
+  GetProduct: "/twirp/acme.catalog.v1.Catalog/GetProduct",
+  ListProducts: "/twirp/acme.catalog.v1.Catalog/ListProducts",
+});
+
+export class CatalogClient {
+  options: RequestOptions;
+
+  constructor(options: RequestOptions = {}) {
+    this.options = options;
+  }
+
+  getProduct(
+    body: GetProductRequest,
+    options: RequestOptions = {},
+  ): Promise<Product> {
+    return request("POST", CatalogPaths.GetProduct, undefined, body, {
+      ...this.options,
+      ...options,
+    });
+  }
+
+  listProducts(
+    body: GetProductRequest,
+    options: RequestOptions = {},
+  ): Promise<Array<Product>> {
+    return request("POST", CatalogPaths.ListProducts, undefined, body, {
+      ...this.options,
+      ...options,
+    });
+  }
+}
+`)
+	assert.Contains(t, client, `  Count: "/rpc/acme.Inventory/Count",`+"\n")
+
+	outputs, _, err = Generate(context.Background(), Config{Patterns: []string{dir}, RPCPrefix: "/"})
+	require.NoError(t, err)
+	assert.Contains(t, string(outputs[filepath.Join(dir, "service_client.js")]), `  GetProduct: "/acme.catalog.v1.Catalog/GetProduct",`+"\n")
+}
+
+func TestRender(t *testing.T) {
+	dir := writeSource(t, "types.go", `package schema
+
+// Status is the lifecycle of a product
+type Status string
+
+const (
+	StatusActive  Status = "active"
+	StatusRetired Status = "retired"
+)
+
+type Product struct {
+	// ID is the product's SKU
+	ID     string  `+"`json:\"id\"`"+`
+	Note   *string `+"`json:\"note,omitempty\"`"+`
+	Status Status  `+"`json:\"status\"`"+`
+}
+`)
+	cfg := Config{Patterns: []string{dir}}
+	schema, _, err := Analyze(context.Background(), cfg)
+	require.NoError(t, err)
+
+	// The model survives a round trip through its JSON encoding
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"type":{"kind":"ref","name":"Status"}`)
+	assert.Contains(t, string(data), `"value":"active"`)
+	var decoded model.Schema
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, model.Version, decoded.Version)
+	require.Len(t, decoded.Files, 1)
+	assert.Equal(t, "schema ("+dir+")", decoded.Files[0].Source)
+
+	types := decoded.Files[0].Types()
+	require.Len(t, types, 2)
+	assert.Equal(t, model.KindEnum, types[0].Kind)
+	assert.Equal(t, []*model.TypeExpr{model.Literal("active"), model.Literal("retired")}, types[0].Literals())
+	assert.Equal(t, "Status is the lifecycle of a product", types[0].Doc)
+	assert.Equal(t, model.Position{File: filepath.Join(dir, "types.go"), Line: 4, Column: 6}, types[0].Pos)
+	assert.Equal(t, []*model.Field{
+		{Name: "id", Type: model.Primitive(model.String), Doc: "ID is the product's SKU", Pos: model.Position{File: filepath.Join(dir, "types.go"), Line: 13, Column: 2}},
+		{Name: "note", Type: model.Primitive(model.String), Optional: true, Pos: model.Position{File: filepath.Join(dir, "types.go"), Line: 14, Column: 2}},
+		{Name: "status", Type: model.Ref("Status"), Pos: model.Position{File: filepath.Join(dir, "types.go"), Line: 15, Column: 2}},
+	}, types[1].Fields)
+
+	outputs, err := Render(&decoded, Config{})
+	require.NoError(t, err)
+	generated, _, err := Generate(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, generated, outputs)
+
+	outputs, err = Render(&decoded, Config{Format: flow.Format{SingleQuote: true, NoSemi: true}})
+	require.NoError(t, err)
+	assert.Contains(t, string(outputs[filepath.Join(dir, "types.js")]), "export type Status = 'active' | 'retired'\n")
+	_, err = Render(&decoded, Config{Format: flow.Format{TrailingComma: "always"}})
+	assert.Error(t, err)
+
+	decoded.Version = model.Version + 1
+	_, err = Render(&decoded, Config{})
+	assert.Error(t, err, "newer version")
+}
+
+// outline emits the names of a module's types, one per line
+type outline struct{}
+
+func (outline) Emit(w io.Writer, f *model.File) error {
+	for _, t := range f.Types() {
+		if _, err := fmt.Fprintf(w, "%s %s\n", t.Kind, t.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (outline) FileName(f *model.File) string {
+	return strings.TrimSuffix(f.Name, ".js") + ".txt"
+}
+
+func TestRegisterEmitter(t *testing.T) {
+	RegisterEmitter("outline", func(Config) Emitter { return outline{} })
+	assert.Contains(t, Languages(), "outline")
+	assert.Contains(t, Languages(), LanguageFlow)
+	assert.Contains(t, Languages(), LanguageJSONSchema)
+
+	dir := writeSource(t, "types.go", `package schema
+
+type Status string
+
+const StatusActive Status = "active"
+
+type Product struct {
+	ID string `+"`json:\"id\"`"+`
+}
+`)
+	outputs, _, err := Generate(context.Background(), Config{Patterns: []string{dir}, Language: "outline"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		filepath.Join(dir, "types.txt"): []byte("enum Status\nstruct Product\n"),
+	}, outputs)
+
+	// A language registered under the name of a built-in one replaces it
+	defer RegisterEmitter(LanguageFlow, emitters[LanguageFlow])
+	RegisterEmitter(LanguageFlow, func(Config) Emitter { return outline{} })
+	outputs, _, err = Generate(context.Background(), Config{Patterns: []string{dir}})
+	require.NoError(t, err)
+	assert.Equal(t, "enum Status\nstruct Product\n", string(outputs[filepath.Join(dir, "types.txt")]))
+
+	_, err = Render(&model.Schema{Version: model.Version}, Config{Language: "typescript"})
+	assert.EqualError(t, err, `unsupported target language "typescript"`)
+}
+
+func TestMergeManual(t *testing.T) {
+	existing := `// @flow
+
+// go2flow:begin manual
+import type { Money } from "./money";
+// go2flow:end manual
+
+export type Status = "active" | "retired";
+// go2flow:begin manual
+export type StatusLabel = { [Status]: string };
+// go2flow:end manual
+
+export type Retired = {
+  since: string,
+};
+
+// go2flow:begin manual
+export function isLive(status: Status): boolean {
+  return status === "active";
+}
+// go2flow:end manual
+`
+	generated := `// @flow
+
+export type Status = "active" | "retired" | "draft";
+
+export type Product = {
+  price: Money,
+};
+`
+	merged, err := MergeManual([]byte(generated), []byte(existing))
+	require.NoError(t, err)
+	assert.Equal(t, `// @flow
+
+// go2flow:begin manual
+import type { Money } from "./money";
+// go2flow:end manual
+
+export type Status = "active" | "retired" | "draft";
+
+// go2flow:begin manual
+export type StatusLabel = { [Status]: string };
+// go2flow:end manual
+
+export type Product = {
+  price: Money,
+};
+
+// go2flow:begin manual
+export function isLive(status: Status): boolean {
+  return status === "active";
+}
+// go2flow:end manual
+`, string(merged))
+
+	// Regenerating keeps the regions in place
+	again, err := MergeManual([]byte(generated), merged)
+	require.NoError(t, err)
+	assert.Equal(t, string(merged), string(again))
+
+	_, err = MergeManual([]byte(generated), []byte("// go2flow:begin manual\nexport type A = string;\n"))
+	assert.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "go2flow")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "imports", "types.js")
+	require.NoError(t, WriteOutputs(map[string][]byte{name: []byte(generated)}))
+	require.NoError(t, ioutil.WriteFile(name, []byte(existing), 0644))
+	require.NoError(t, WriteOutputs(map[string][]byte{name: []byte(generated)}))
+	written, err := ioutil.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, string(merged), string(written))
+
+	stale, err := StaleOutputs(map[string][]byte{name: []byte(generated)})
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+	missing := filepath.Join(dir, "missing.js")
+	stale, err = StaleOutputs(map[string][]byte{name: []byte(generated + "export type Draft = string;\n"), missing: nil})
+	require.NoError(t, err)
+	assert.Equal(t, []string{name, missing}, stale)
+}
+
+func TestStaleOutputsIgnoresCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go2flow")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "types.js")
+	module := "// @flow\n// @generated by go2flow from ./schema\n// Regenerate with: %s\n\nexport type Status = string;\n"
+	require.NoError(t, WriteOutputs(map[string][]byte{name: []byte(fmt.Sprintf(module, "go2flow -d ./schema --write"))}))
+
+	stale, err := StaleOutputs(map[string][]byte{name: []byte(fmt.Sprintf(module, "go2flow -d ./schema --check"))})
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+	stale, err = StaleOutputs(map[string][]byte{name: []byte(strings.Replace(fmt.Sprintf(module, "go2flow"), "./schema", "./api", 1))})
+	require.NoError(t, err)
+	assert.Equal(t, []string{name}, stale)
+}
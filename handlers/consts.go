@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"strings"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/kristiehoward/go2flow/typeutils"
+)
+
+// maxSafeInteger is the largest integer a JS number represents exactly
+const maxSafeInteger = 1<<53 - 1
+
+// isExportedConst reports whether the constant is exported to JS, either
+// marked with //go2flow:export on its spec or declaration, or by the
+// ExportConsts option unless marked with //go2flow:export false. Constants of
+// the package's enum types belong to the enums, so the option leaves them out.
+func (h *Handler) isExportedConst(d ast.GenDecl, vs *ast.ValueSpec, c *typeutils.Const) (exported, explicit bool) {
+	args, ok := typeutils.ParseDirectives(vs.Doc, vs.Comment)["export"]
+	if !ok {
+		args, ok = typeutils.ParseDirectives(d.Doc)["export"]
+	}
+	if ok {
+		return args != "false", args != "false"
+	}
+	if h.isEnum(c.Type) {
+		return false, false
+	}
+	return h.ExportConsts, false
+}
+
+// HandleConstDecl adds the selected constants of a package level const
+// declaration, which are exported as JS constants whose Flow types are their
+// literal values, e.g.
+//
+//	export const MaxPageSize: 100 = 100;
+func (h *Handler) HandleConstDecl(d ast.GenDecl) {
+	pkg := h.Types.Pkg
+	if d.Tok != token.CONST || pkg == nil {
+		return
+	}
+
+	decl := &model.Decl{}
+	for _, spec := range d.Specs {
+		vs := spec.(*ast.ValueSpec)
+		for _, name := range vs.Names {
+			c, ok := pkg.Consts[name.Name]
+			if !ok || !name.IsExported() {
+				continue
+			}
+			exported, explicit := h.isExportedConst(d, vs, c)
+			if !exported {
+				continue
+			}
+			value, ok := h.constValue(name, explicit)
+			if !ok {
+				continue
+			}
+			decl.Consts = append(decl.Consts, &model.Const{
+				Name:  name.Name,
+				Value: value,
+				Doc:   strings.TrimSpace(vs.Doc.Text()),
+				Pos:   h.pos(name),
+			})
+		}
+	}
+	if len(decl.Consts) > 0 {
+		h.File.Decls = append(h.File.Decls, decl)
+	}
+}
+
+// constValue returns the constant's value for the model. Values that can't be
+// represented are reported when the constant was explicitly selected.
+func (h *Handler) constValue(name *ast.Ident, explicit bool) (interface{}, bool) {
+	value, ok := h.Types.Pkg.ConstValue(name.Name)
+	if !ok || value.Kind() == constant.Complex {
+		if explicit {
+			h.Types.Report(name, "can't evaluate the constant %s", name.Name)
+		}
+		return nil, false
+	}
+	if value.Kind() == constant.Int {
+		if n, exact := constant.Int64Val(value); !exact || n > maxSafeInteger || n < -maxSafeInteger {
+			if explicit {
+				h.Types.Report(name, "the constant %s can't be represented exactly by a JS number", name.Name)
+			}
+			return nil, false
+		}
+	}
+	return typeutils.LiteralValue(value), true
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"go/ast"
+	"strings"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/kristiehoward/go2flow/typeutils"
+)
+
+// isEnum reports whether the constants declared with the named type form an
+// enum: the type is marked with a //go2flow:enum directive, it has stringer
+// names, or it has several constants, or one named after the type, e.g.
+// StatusActive. A lone other constant, e.g. a default value such as
+// DefaultWeight, leaves the type its primitive type, and //go2flow:enum false
+// opts out.
+func (h *Handler) isEnum(typeName string) bool {
+	pkg := h.Types.Pkg
+	if pkg == nil || pkg.Types[typeName] == nil {
+		return false
+	}
+	if args, ok := typeutils.ParseDirectives(h.typeDoc(*pkg.Types[typeName]))["enum"]; ok {
+		return args != "false"
+	}
+	if _, ok := pkg.StringerNames(typeName); ok {
+		return true
+	}
+	consts := pkg.ConstsOfType(typeName)
+	switch len(consts) {
+	case 0:
+		return false
+	case 1:
+		return strings.HasPrefix(consts[0].Name, typeName) || usesIota(consts[0].Value)
+	}
+	return true
+}
+
+// usesIota reports whether a constant's value expression refers to iota
+func usesIota(value ast.Expr) bool {
+	found := false
+	if value != nil {
+		ast.Inspect(value, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && ident.Name == "iota" {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// enumMembers returns the constants declared with the named type, or false if
+// they don't form an enum or their values can't be evaluated
+func (h *Handler) enumMembers(typeName string) ([]*model.EnumValue, bool) {
+	pkg := h.Types.Pkg
+	if !h.isEnum(typeName) {
+		return nil, false
+	}
+	consts := pkg.ConstsOfType(typeName)
+	if len(consts) == 0 {
+		return nil, false
+	}
+
+	var members []*model.EnumValue
+	for _, c := range consts {
+		if c.Name == "_" {
+			continue
+		}
+		value, ok := pkg.ConstValue(c.Name)
+		if !ok {
+			h.Types.Report(c.Spec, "cannot evaluate the value of %s", c.Name)
+			return nil, false
+		}
+		members = append(members, newEnumMember(typeName, c, typeutils.LiteralValue(value)))
+	}
+	return members, len(members) > 0
+}
+
+// stringerMembers returns the constants of an integer enum type that is
+// encoded as text through a stringer generated String method, whose values are
+// the names stringer gives them rather than numbers
+func (h *Handler) stringerMembers(typeName string) ([]*model.EnumValue, bool) {
+	pkg := h.Types.Pkg
+	if !pkg.HasMethod(typeName, "MarshalText") || !pkg.HasMethod(typeName, "String") {
+		return nil, false
+	}
+	names, ok := pkg.StringerNames(typeName)
+	if !ok {
+		return nil, false
+	}
+	values, consts, err := pkg.SortedConstValues(typeName)
+	if err != nil || len(values) != len(names) {
+		h.Types.Report(pkg.Types[typeName], "the constants of %s don't match its stringer names, run go generate", typeName)
+		return nil, false
+	}
+
+	// stringer names each distinct value in ascending order
+	var members []*model.EnumValue
+	for i, v := range values {
+		for _, c := range consts[v.ExactString()] {
+			members = append(members, newEnumMember(typeName, c, names[i]))
+		}
+	}
+	return members, true
+}
+
+func newEnumMember(typeName string, c *typeutils.Const, value interface{}) *model.EnumValue {
+	member := &model.EnumValue{Key: enumKey(typeName, c.Name), Value: value}
+	member.Label = strings.Join(strings.Fields(c.Doc.Text()), " ")
+	if member.Label == "" && c.Spec.Comment != nil {
+		member.Label = strings.Join(strings.Fields(c.Spec.Comment.Text()), " ")
+	}
+	if member.Label == "" {
+		member.Label = member.Key
+	}
+	return member
+}
+
+// addEnum adds an enum type, with its values at runtime if EnumObjects is set
+func (h *Handler) addEnum(ts ast.TypeSpec, members []*model.EnumValue) {
+	t := h.newType(ts, model.KindEnum)
+	t.Values = members
+	t.Runtime = h.EnumObjects
+	h.addType(t)
+}
+
+// enumKey trims the enum type's name from a constant's name, e.g. StatusActive
+// or ActiveStatus to Active
+func enumKey(typeName, constName string) string {
+	if key := strings.TrimPrefix(constName, typeName); key != constName && key != "" && key[0] >= 'A' && key[0] <= 'Z' {
+		return key
+	}
+	if key := strings.TrimSuffix(constName, typeName); key != constName && key != "" {
+		return key
+	}
+	return constName
+}
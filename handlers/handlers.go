@@ -3,71 +3,380 @@ package handlers
 import (
 	"fmt"
 	"go/ast"
+	"strings"
 
+	"github.com/kristiehoward/go2flow/model"
 	"github.com/kristiehoward/go2flow/typeutils"
 )
 
-func handleField(f ast.Field) {
-	tag := f.Tag.Value
-	// A field is optional if the json tag includes `omitempty`
-	name, isOptional := typeutils.GetTagInfo(tag)
+// Handler builds the model of the module generated for the Go declarations it
+// handles
+type Handler struct {
+	// File receives the model of the declarations
+	File *model.File
+	// Types translates Go type expressions into the types of the model
+	Types *typeutils.Translator
+	// TagKeys are the struct tag keys, in order of precedence, that decide the
+	// property names and optionality of struct fields. Defaults to
+	// typeutils.DefaultTagKeys.
+	TagKeys []string
+	// JSONv2 follows the semantics of encoding/json/v2 for json tags
+	JSONv2 bool
+	// Opaque makes the types defined from a string, number or boolean opaque,
+	// as if they were marked with a //go2flow:opaque directive
+	Opaque bool
+	// EnumObjects emits the values of enum types at runtime, along with their
+	// labels, in addition to their type
+	EnumObjects bool
+	// ExportConsts emits the package's exported constants that don't belong to
+	// an enum, as if they were marked with a //go2flow:export directive
+	ExportConsts bool
+
+	// structType is the model of the struct being handled
+	structType *model.Type
+}
+
+// NewHandler returns a Handler building a new module with the given type
+// translator
+func NewHandler(types *typeutils.Translator) *Handler {
+	return &Handler{File: &model.File{}, Types: types}
+}
+
+// pos returns the position of a node in the model
+func (h *Handler) pos(node ast.Node) model.Position {
+	if h.Types.Fset == nil {
+		return model.Position{}
+	}
+	p := h.Types.Fset.Position(node.Pos())
+	return model.Position{File: p.Filename, Line: p.Line, Column: p.Column}
+}
+
+// newType returns the model of a type definition, with its doc comment and
+// position
+func (h *Handler) newType(ts ast.TypeSpec, kind model.Kind) *model.Type {
+	return &model.Type{
+		Name: ts.Name.Name,
+		Kind: kind,
+		Doc:  strings.TrimSpace(h.typeDoc(ts).Text()),
+		Pos:  h.pos(ts.Name),
+	}
+}
+
+// addType adds a type definition to the module
+func (h *Handler) addType(t *model.Type) {
+	h.File.Decls = append(h.File.Decls, &model.Decl{Type: t})
+}
+
+// addAlias adds a type definition other than a struct or an enum
+func (h *Handler) addAlias(ts ast.TypeSpec, kind model.Kind, typ *model.TypeExpr) {
+	t := h.newType(ts, kind)
+	t.Type = typ
+	h.addType(t)
+}
+
+// addField adds a field to the struct being handled, with the doc or line
+// comment and position of the Go field
+func (h *Handler) addField(f ast.Field, field *model.Field) {
+	field.Doc = strings.TrimSpace(f.Doc.Text())
+	if field.Doc == "" {
+		field.Doc = strings.TrimSpace(f.Comment.Text())
+	}
+	field.Pos = h.pos(&f)
+	h.structType.Fields = append(h.structType.Fields, field)
+}
+
+func (h *Handler) tagKeys() []string {
+	if len(h.TagKeys) == 0 {
+		return typeutils.DefaultTagKeys
+	}
+	return h.TagKeys
+}
+
+// handleField adds the property for a struct field. The field's type is
+// translated from its Go type unless fieldType is given.
+func (h *Handler) handleField(f ast.Field, fieldType *model.TypeExpr) {
+	tag := ""
+	if f.Tag != nil {
+		tag = f.Tag.Value
+	}
+	// A field is optional if its tag includes `omitempty`
+	info, isTagged := typeutils.ParseTag(tag, h.tagKeys())
 	// A field is nullable if the identifier is a pointer (nil pointer --> null JSON)
 	isNullable := typeutils.IsNullable(f)
+	isEmbedded := len(f.Names) == 0
+	if info.Skip {
+		return
+	}
+
+	isJSONv2 := h.JSONv2 && info.Key == "json"
+	if isJSONv2 {
+		// encoding/json/v2 inlines fields explicitly, and writes the unknown
+		// members a field collected back into the enclosing object
+		info.Inline = info.HasOption("inline") || info.HasOption("unknown")
+	}
+
+	// encoding/json, v1 and v2, promotes the fields of embedded structs that
+	// it isn't given a name for, the other encoders only when told to inline
+	// them
+	promoted := isEmbedded && info.Name == "" && (isTagged && info.Key == "json" || !isTagged && h.usesJSON())
+	if info.Inline || promoted {
+		h.handleInlineField(f)
+		return
+	}
+	if !isTagged {
+		// Only handle fields with a tag for one of the encoders
+		return
+	}
+
+	name := info.Name
 	if name == "" {
+		name = typeutils.DefaultFieldName(info.Key, fieldName(f))
+	}
+	// Kubernetes types mark fields optional without necessarily omitting them
+	markers := typeutils.ParseMarkers(f.Doc, f.Comment)
+	isOptional := info.IsOptional || markers.IsOptional()
+	if isJSONv2 && !info.HasOption("omitzero") && !h.Types.OmitsEmptyV2(f.Type) {
+		// encoding/json/v2 only omits values that encode as null, "", {} or []
+		isOptional = markers.IsOptional()
+	} else if info.Key == "json" && !isJSONv2 && !info.HasOption("omitzero") && !h.Types.OmitsEmpty(f.Type) {
+		// encoding/json never omits structs, which are only optional if marked so
+		isOptional = markers.IsOptional()
+	}
+
+	// Request structs are validated by their `validate` or `binding` tag,
+	// and a required field is present and non-zero, so neither omitted nor
+	// null
+	validation := typeutils.ParseValidation(tag)
+	if validation.Required {
+		isOptional, isNullable = false, false
+	}
+
+	if fieldType == nil {
+		fieldType = h.fieldType(f, info, isJSONv2)
+		if enum, ok := h.markerEnum(f.Type, markers, fieldType); ok {
+			fieldType = enum
+		} else if oneOf, ok := h.oneOfType(f, validation, fieldType); ok {
+			fieldType = oneOf
+		}
+	}
+
+	// If a type is optional AND nullable, it will not show up in the json
+	// response, so we can assume the types here are required
+	h.addField(f, &model.Field{
+		Name:        name,
+		Type:        fieldType,
+		Optional:    isOptional,
+		Nullable:    isNullable && !isOptional,
+		Constraints: h.constraints(f, validation),
+	})
+}
+
+// fieldType returns the type of a field, taking the encoding options of its
+// json tag into account
+func (h *Handler) fieldType(f ast.Field, info typeutils.TagInfo, isJSONv2 bool) *model.TypeExpr {
+	if isJSONv2 {
+		for _, option := range info.Options {
+			if strings.HasPrefix(option, "format:") {
+				if t, ok := h.Types.FormatType(f.Type, strings.TrimPrefix(option, "format:")); ok {
+					return t
+				}
+			}
+		}
+	}
+
+	t := h.Types.TypeOf(f.Type)
+	// `string` encodes numbers and booleans within a JSON string
+	if info.Key == "json" && info.HasOption("string") && t.IsPrimitive(model.Number, model.Boolean) {
+		return model.Primitive(model.String)
+	}
+	return t
+}
+
+// markerEnum returns the literal union of a field's
+// `+kubebuilder:validation:Enum` marker. As with kubebuilder, the marker
+// applies to the items of a slice or an array.
+func (h *Handler) markerEnum(expr ast.Expr, markers typeutils.Markers, valueType *model.TypeExpr) (*model.TypeExpr, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	t, ok := expr.(*ast.ArrayType)
+	if !ok || typeutils.IsByteSlice(t) {
+		return markers.Enum(valueType)
+	}
+	enum, ok := h.markerEnum(t.Elt, markers, h.Types.TypeOf(t.Elt))
+	if !ok {
+		return nil, false
+	}
+	return h.Types.ArrayOf(t, enum), true
+}
+
+// handleInlineField adds the properties of a field that the encoder flattens
+// into the enclosing object: a map becomes the object's indexer, and any other
+// type is spread into it
+func (h *Handler) handleInlineField(f ast.Field) {
+	fieldType := f.Type
+	if star, ok := fieldType.(*ast.StarExpr); ok {
+		fieldType = star.X
+	}
+	if m, ok := h.Types.Underlying(fieldType).(*ast.MapType); ok {
+		h.addField(f, &model.Field{Key: h.Types.TypeOf(m.Key), Type: h.Types.TypeOf(m.Value)})
+		return
+	}
+	if sel, ok := fieldType.(*ast.SelectorExpr); ok && sel.Sel.Name == "Value" && fmt.Sprint(sel.X) == "jsontext" {
+		// Unknown members collected as a jsontext.Value
+		h.addField(f, &model.Field{Key: model.Primitive(model.String), Type: model.Primitive(model.Unknown)})
 		return
 	}
+	h.addField(f, &model.Field{Type: h.Types.TypeOf(fieldType), Spread: true})
+}
+
+// usesJSON reports whether the json struct tag key is consulted
+func (h *Handler) usesJSON() bool {
+	for _, key := range h.tagKeys() {
+		if key == "json" {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldName returns the Go name of a field, which for an embedded field is the
+// name of its type
+func fieldName(f ast.Field) string {
+	if len(f.Names) > 0 {
+		return f.Names[0].Name
+	}
+	fieldType := f.Type
+	if star, ok := fieldType.(*ast.StarExpr); ok {
+		fieldType = star.X
+	}
+	switch t := fieldType.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
 
-	fmt.Printf("  %s", name)
-	if isOptional {
-		// https://flow.org/en/docs/types/primitives/#toc-optional-object-properties
-		fmt.Printf("?: ")
-	} else if isNullable {
-		// If a type is optional AND nullable, it will not show up in the json
-		// response, so we can assume the types here are required
-		// https://flow.org/en/docs/types/primitives/#toc-maybe-types
-		fmt.Printf(": ?")
-	} else {
-		fmt.Printf(": ")
+// typeDoc returns the doc comment of a type definition, falling back to the
+// doc comment of its declaration from the package index
+func (h *Handler) typeDoc(ts ast.TypeSpec) *ast.CommentGroup {
+	if ts.Doc == nil && h.Types.Pkg != nil {
+		return h.Types.Pkg.TypeDocs[ts.Name.Name]
 	}
+	return ts.Doc
+}
 
-	fieldType := typeutils.GetTypeInfo(f.Type)
-	fmt.Print(fieldType)
-	fmt.Printf(",\n")
+// marshalerType returns the type of a type definition that encodes itself: a
+// type mapping for the type applies to its definition, a json.Marshaler can't
+// be translated without one, and an encoding.TextMarshaler is a string
+func (h *Handler) marshalerType(ts ast.TypeSpec) (*model.TypeExpr, bool) {
+	name := ts.Name.Name
+	if mapped, ok := h.Types.TypeMap[name]; ok {
+		return mapped.Clone(), true
+	}
+	pkg := h.Types.Pkg
+	if pkg.HasMethod(name, "MarshalJSON") {
+		h.Types.Report(ts.Name, "%s implements json.Marshaler, add a type mapping for its encoding", name)
+		return model.Primitive(model.Unknown), true
+	}
+	if pkg.HasMethod(name, "MarshalText") {
+		return model.Primitive(model.String), true
+	}
+	return nil, false
 }
 
-// HandleTypeDef
-func HandleTypeDef(ts ast.TypeSpec) {
+// HandleTypeDef adds the definition of an exported type definition
+func (h *Handler) HandleTypeDef(ts ast.TypeSpec) {
 	if !ts.Name.IsExported() {
 		// Do not handle unexported structs
 		return
 	}
+	markers := typeutils.ParseMarkers(h.typeDoc(ts))
+	if markers.IsExcluded() {
+		// Do not handle types excluded from the API with +k8s:openapi-gen=false
+		return
+	}
+
+	// type MyAlias = AnotherType shares the methods, and so the encoding, of
+	// AnotherType
+	if ts.Assign.IsValid() {
+		h.addAlias(ts, model.KindAlias, h.Types.TypeOf(ts.Type))
+		return
+	}
+	if members, ok := h.stringerMembers(ts.Name.Name); ok {
+		h.addEnum(ts, members)
+		return
+	}
+	if t, ok := h.marshalerType(ts); ok {
+		h.addAlias(ts, model.KindAlias, t)
+		return
+	}
 
-	switch t := ts.Type.(type) {
+	// A defined type doesn't have the methods of the type it's defined from, so
+	// its encoding follows the underlying type
+	switch t := h.Types.Underlying(ts.Type).(type) {
 	// type MyAlias string
 	// type MyAlias2 AnotherType
-	case *ast.Ident:
-		fmt.Printf("export type %s = %s;\n\n", ts.Name, typeutils.GetTypeInfo(t))
+	case *ast.Ident, *ast.SelectorExpr:
+		typ := h.Types.TypeOf(t)
+		if enum, ok := markers.Enum(typ); ok {
+			typ = enum
+		} else if members, ok := h.enumMembers(ts.Name.Name); ok && isPrimitive(typ) {
+			// type Status string with constants of type Status
+			h.addEnum(ts, members)
+			return
+		} else if h.isOpaque(ts) && isPrimitive(typ) {
+			h.addAlias(ts, model.KindOpaque, typ)
+			return
+		}
+		h.addAlias(ts, model.KindAlias, typ)
 		return
 	// type MyAlias []AnotherType
-	case *ast.ArrayType:
-		elementType := typeutils.GetTypeInfo(t.Elt)
-		fmt.Printf("export type %s = Array<%s>;\n\n", ts.Name, elementType)
-		return
 	// type MyAlias map[boolean]AnotherType
-	case *ast.MapType:
-		keyType := typeutils.GetTypeInfo(t.Key)
-		valueType := typeutils.GetTypeInfo(t.Value)
-		fmt.Printf("export type %s = {[%s]: %s};\n\n", ts.Name, keyType, valueType)
+	case *ast.ArrayType, *ast.MapType:
+		h.addAlias(ts, model.KindAlias, h.Types.TypeOf(t))
 		return
 	case *ast.StructType:
-		fmt.Printf("export type %s {\n", ts.Name)
+		h.structType = h.newType(ts, model.KindStruct)
+		h.addType(h.structType)
+		// Members of a discriminated union have a literal type for their tag
+		tagField, tagLiteral, isMember := h.memberTag(ts.Name.Name)
 		fields := t.Fields.List
 		for _, field := range fields {
-			handleField(*field)
+			var fieldType *model.TypeExpr
+			if isMember && len(field.Names) == 1 && field.Names[0].Name == tagField {
+				fieldType = tagLiteral
+			}
+			h.handleField(*field, fieldType)
+		}
+		return
+	// type MyUnion interface { isMyUnion() }
+	case *ast.InterfaceType:
+		if h.isService(ts) {
+			// Services are generated in client modules
+			return
+		}
+		u, sealed := h.sealedUnion(t)
+		if !sealed {
+			break
+		}
+		for _, name := range u.Unexported {
+			h.Types.Warn(ts.Name, "unexported %s implements sealed interface %s and is left out of its union", name, ts.Name)
+		}
+		if len(u.Members) == 0 {
+			h.Types.Report(ts.Name, "no implementations of sealed interface %s", ts.Name)
+			return
+		}
+		members := make([]*model.TypeExpr, len(u.Members))
+		for i, name := range u.Members {
+			members[i] = model.Ref(name)
 		}
-		fmt.Printf("}\n\n")
+		h.addAlias(ts, model.KindUnion, model.UnionOf(members...))
 		return
-		// Don't handle anything else
 	}
+	// Don't handle anything else
+	h.Types.Report(ts.Type, "unsupported type definition %s", ts.Name)
 	return
 }
@@ -0,0 +1,655 @@
+package handlers
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/kristiehoward/go2flow/flow"
+	"github.com/kristiehoward/go2flow/typeutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// translate handles every type definition of the Go source and returns the
+// generated Flow definitions along with the translator used
+func translate(t *testing.T, src string) (string, *typeutils.Translator) {
+	types := typeutils.NewTranslator(nil)
+	types.Fset = token.NewFileSet()
+	file, err := parser.ParseFile(types.Fset, "types.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	types.Pkg = typeutils.NewPackage(file)
+
+	h := NewHandler(types)
+	ast.Inspect(file, func(node ast.Node) bool {
+		if ts, ok := node.(*ast.TypeSpec); ok {
+			h.HandleTypeDef(*ts)
+		}
+		return true
+	})
+	return printFlow(t, h), types
+}
+
+// printFlow returns the Flow module of the definitions the handler added
+func printFlow(t *testing.T, h *Handler) string {
+	var buf bytes.Buffer
+	require.NoError(t, flow.Print(&buf, h.File))
+	return buf.String()
+}
+
+func TestSealedInterface(t *testing.T) {
+	out, types := translate(t, `package shapes
+
+type ShapeKind string
+
+const (
+	ShapeKindCircle ShapeKind = "circle"
+	ShapeKindSquare ShapeKind = "square"
+)
+
+type Shape interface {
+	isShape()
+}
+
+type Circle struct {
+	Kind   ShapeKind `+"`json:\"kind\"`"+`
+	Radius int       `+"`json:\"radius\"`"+`
+}
+
+func (Circle) isShape() {}
+
+type Square struct {
+	Kind ShapeKind `+"`json:\"kind\"`"+`
+	Side int       `+"`json:\"side\"`"+`
+}
+
+func (*Square) isShape() {}
+
+type Unsealed interface {
+	Area() int
+}
+`)
+	assert.Equal(t, `export type ShapeKind = "circle" | "square";
+
+export type Shape = Circle | Square;
+
+export type Circle = {
+  kind: "circle",
+  radius: number,
+};
+
+export type Square = {
+  kind: "square",
+  side: number,
+};
+`, out)
+	require.Len(t, types.Diagnostics, 1)
+	assert.Equal(t, "types.go:28:15: unsupported type definition Unsealed", types.Diagnostics[0].String())
+}
+
+func TestSealedInterfaceWithoutTag(t *testing.T) {
+	out, _ := translate(t, `package events
+
+type Event interface{ isEvent() }
+
+type Created struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+func (Created) isEvent() {}
+
+type Deleted struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+func (Deleted) isEvent() {}
+`)
+	assert.Contains(t, out, "export type Event = Created | Deleted;\n")
+	assert.Contains(t, out, "  id: string,\n")
+}
+
+func TestSealedInterfaceUnexportedMember(t *testing.T) {
+	out, types := translate(t, `package events
+
+type Event interface{ isEvent() }
+
+type Created struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+func (Created) isEvent() {}
+
+type replayed struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+func (replayed) isEvent() {}
+`)
+	assert.Contains(t, out, "export type Event = Created;\n")
+	assert.NotContains(t, out, "replayed")
+	require.Len(t, types.Diagnostics, 1)
+	assert.Equal(t, "types.go:3:6: warning: unexported replayed implements sealed interface Event and is left out of its union", types.Diagnostics[0].String())
+}
+
+func TestTagKeys(t *testing.T) {
+	types := typeutils.NewTranslator(nil)
+	types.Fset = token.NewFileSet()
+	file, err := parser.ParseFile(types.Fset, "types.go", `package config
+
+type Base struct {
+	Region string `+"`yaml:\"region\"`"+`
+}
+
+type Config struct {
+	Base     `+"`yaml:\",inline\"`"+`
+	Replicas int               `+"`yaml:\",omitempty\" json:\"replicas\"`"+`
+	Labels   map[string]string `+"`mapstructure:\",remain\"`"+`
+	Secret   string            `+"`yaml:\"-\"`"+`
+}
+`, parser.ParseComments)
+	require.NoError(t, err)
+	types.Pkg = typeutils.NewPackage(file)
+
+	h := NewHandler(types)
+	h.TagKeys = []string{"yaml", "mapstructure"}
+	h.HandleTypeDef(*types.Pkg.Types["Config"])
+	assert.Equal(t, `export type Config = {
+  ...Base,
+  replicas?: number,
+  [string]: string,
+};
+`, printFlow(t, h))
+}
+
+func TestEmbeddedJSON(t *testing.T) {
+	out, _ := translate(t, `package schema
+
+type Meta struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+type Product struct {
+	*Meta
+	Price int `+"`json:\",omitempty\"`"+`
+}
+`)
+	assert.Contains(t, out, `export type Product = {
+  ...Meta,
+  Price?: number,
+};
+`)
+}
+
+func TestKubernetesMarkers(t *testing.T) {
+	out, _ := translate(t, `package v1
+
+// +kubebuilder:validation:Enum=Pending;Running;Failed
+type Phase string
+
+// Internal is not part of the API
+// +k8s:openapi-gen=false
+type Internal struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+type Status struct {
+	// +optional
+	Phase Phase `+"`json:\"phase\"`"+`
+	// +kubebuilder:validation:Enum=1;2;3
+	Replicas int `+"`json:\"replicas\"`"+`
+	// +kubebuilder:validation:Enum=ReadWriteOnce;ReadOnlyMany
+	Modes []string `+"`json:\"modes\"`"+`
+	// +kubebuilder:validation:Enum=x;y
+	Axes [2]string `+"`json:\"axes\"`"+`
+}
+`)
+	assert.Equal(t, `export type Phase = "Pending" | "Running" | "Failed";
+
+export type Status = {
+  phase?: Phase,
+  replicas: 1 | 2 | 3,
+  modes: Array<"ReadWriteOnce" | "ReadOnlyMany">,
+  axes: ["x" | "y", "x" | "y"],
+};
+`, out)
+}
+
+func TestAliasesAndDefinedTypes(t *testing.T) {
+	out, types := translate(t, `package schema
+
+import "time"
+
+type BoolAlias bool
+
+func (b BoolAlias) MarshalJSON() ([]byte, error) { return nil, nil }
+
+type AliasType BoolAlias
+type RealAlias = BoolAlias
+type Timestamp = time.Time
+
+type Level int
+
+func (l Level) MarshalText() ([]byte, error) { return nil, nil }
+
+type Levels []Level
+type Base struct {
+	Name string `+"`json:\"name\"`"+`
+}
+type Derived Base
+`)
+	assert.Equal(t, `export type BoolAlias = mixed;
+
+export type AliasType = boolean;
+
+export type RealAlias = BoolAlias;
+
+export type Timestamp = string;
+
+export type Level = string;
+
+export type Levels = Array<Level>;
+
+export type Base = {
+  name: string,
+};
+
+export type Derived = {
+  name: string,
+};
+`, out)
+	require.Len(t, types.Diagnostics, 1)
+	assert.Equal(t, "types.go:5:6: BoolAlias implements json.Marshaler, add a type mapping for its encoding", types.Diagnostics[0].String())
+}
+
+func TestOmitEmpty(t *testing.T) {
+	out, _ := translate(t, `package schema
+
+import "time"
+
+type Point struct {
+	X int `+"`json:\"x\"`"+`
+}
+
+type Location Point
+
+type Event struct {
+	At       time.Time         `+"`json:\"at,omitempty\"`"+`
+	Where    Location          `+"`json:\"where,omitempty\"`"+`
+	Window   [2]int            `+"`json:\"window,omitempty\"`"+`
+	Count    int               `+"`json:\"count,omitempty\"`"+`
+	Tags     []string          `+"`json:\"tags,omitempty\"`"+`
+	Labels   map[string]string `+"`json:\"labels,omitempty\"`"+`
+	Next     *Point            `+"`json:\"next,omitempty\"`"+`
+	Deadline time.Time         `+"`json:\"deadline,omitzero\"`"+`
+	Origin   Point             `+"`json:\"origin,omitzero\"`"+`
+}
+`)
+	assert.Contains(t, out, `export type Event = {
+  at: string,
+  where: Location,
+  window: [number, number],
+  count?: number,
+  tags?: Array<string>,
+  labels?: { [string]: string },
+  next?: Point,
+  deadline?: string,
+  origin?: Point,
+};
+`)
+}
+
+func TestValidationTags(t *testing.T) {
+	out, _ := translate(t, `package api
+
+type Role string
+
+type CreateUser struct {
+	Name   string            `+"`json:\"name,omitempty\" validate:\"required,min=1,max=64\"`"+`
+	Email  *string           `+"`json:\"email,omitempty\" binding:\"required\"`"+`
+	Role   Role              `+"`json:\"role\" validate:\"oneof=admin 'read only'\"`"+`
+	Level  int               `+"`json:\"level,omitempty\" validate:\"omitempty,oneof=1 2 3\"`"+`
+	Age    int               `+"`json:\"age\" binding:\"gte=0,lt=130\"`"+`
+	Tags   []string          `+"`json:\"tags\" validate:\"max=10,dive,min=1\"`"+`
+	Labels map[string]string `+"`json:\"labels\" validate:\"gt=0\"`"+`
+	Nick   string            `+"`json:\"nick\" validate:\"len=8|len=0\"`"+`
+}
+`)
+	assert.Contains(t, out, `export type CreateUser = {
+  /**
+   * @minLength 1
+   * @maxLength 64
+   */
+  name: string,
+  email: string,
+  role: "admin" | "read only",
+  level?: 1 | 2 | 3,
+  /**
+   * @minimum 0
+   * @exclusiveMaximum 130
+   */
+  age: number,
+  /**
+   * @maxItems 10
+   */
+  tags: Array<string>,
+  /**
+   * @minProperties 1
+   */
+  labels: { [string]: string },
+  nick: string,
+};
+`)
+}
+
+func TestJSONv2(t *testing.T) {
+	types := typeutils.NewTranslator(nil)
+	types.Fset = token.NewFileSet()
+	file, err := parser.ParseFile(types.Fset, "types.go", `package schema
+
+import "time"
+
+type Meta struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+type Config struct {
+	Meta
+	Count   int            `+"`json:\"count,omitempty\"`"+`
+	Label   string         `+"`json:\"label,omitempty\"`"+`
+	Since   time.Time      `+"`json:\"since,format:unix\"`"+`
+	When    *time.Time     `+"`json:\"when,format:unix\"`"+`
+	Until   *time.Time     `+"`json:\"until,omitzero,format:unix\"`"+`
+	Values  *[]int         `+"`json:\"values,format:emitnull\"`"+`
+	Timeout time.Duration  `+"`json:\"timeout,format:units\"`"+`
+	Data    []byte         `+"`json:\"data,format:array\"`"+`
+	ID      int64          `+"`json:\"id,string\"`"+`
+	Extra   map[string]any `+"`json:\",inline\"`"+`
+}
+`, parser.ParseComments)
+	require.NoError(t, err)
+	types.Pkg = typeutils.NewPackage(file)
+
+	h := NewHandler(types)
+	h.JSONv2 = true
+	h.HandleTypeDef(*types.Pkg.Types["Config"])
+	assert.Equal(t, `export type Config = {
+  ...Meta,
+  count: number,
+  label?: string,
+  since: number,
+  when: ?number,
+  until?: number,
+  values: ?Array<number>,
+  timeout: string,
+  data: Array<number>,
+  id: string,
+  [string]: mixed,
+};
+`, printFlow(t, h))
+}
+
+func TestOpaqueTypes(t *testing.T) {
+	out, _ := translate(t, `package schema
+
+//go2flow:opaque
+type ProductID string
+
+type UserID string
+`)
+	assert.Equal(t, `export opaque type ProductID: string = string;
+
+export function toProductID(value: string): ProductID {
+  return value;
+}
+
+export function asProductID(value: mixed): ?ProductID {
+  if (typeof value === "string") {
+    return value;
+  }
+  return null;
+}
+
+export function assertProductID(value: mixed): ProductID {
+  if (typeof value !== "string") {
+    throw new TypeError("Expected ProductID to be a string");
+  }
+  return value;
+}
+
+export type UserID = string;
+`, out)
+}
+
+func TestEnums(t *testing.T) {
+	src := `package schema
+
+type Status string
+
+const (
+	// The product is live
+	StatusActive Status = "active"
+	StatusRetired Status = "retired" // No longer sold
+)
+
+type Priority int
+
+const (
+	PriorityLow Priority = iota + 1
+	PriorityHigh
+)
+`
+	out, _ := translate(t, src)
+	assert.Equal(t, `export type Status = "active" | "retired";
+
+export type Priority = 1 | 2;
+`, out)
+
+	types := typeutils.NewTranslator(nil)
+	file, err := parser.ParseFile(token.NewFileSet(), "types.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	types.Pkg = typeutils.NewPackage(file)
+	h := NewHandler(types)
+	h.EnumObjects = true
+	h.HandleTypeDef(*types.Pkg.Types["Status"])
+	assert.Equal(t, `export const StatusValues = Object.freeze({
+  Active: "active",
+  Retired: "retired",
+});
+
+export type Status = $Values<typeof StatusValues>;
+
+export const StatusOptions: $ReadOnlyArray<{| value: Status, label: string |}> =
+  Object.freeze([
+    { value: StatusValues.Active, label: "The product is live" },
+    { value: StatusValues.Retired, label: "No longer sold" },
+  ]);
+`, printFlow(t, h))
+}
+
+func TestEnumInference(t *testing.T) {
+	out, _ := translate(t, `package schema
+
+type Weight int
+
+const DefaultWeight Weight = 1000
+
+type Region string
+
+const (
+	RegionEU Region = "eu"
+	RegionUS Region = "us"
+)
+
+//go2flow:enum
+type Tier string
+
+const (
+	TierFree Tier = "free"
+	TierPro  Tier = "pro"
+)
+
+//go2flow:enum false
+type Flags int
+
+const (
+	FlagA Flags = 1 << iota
+	FlagB
+)
+`)
+	assert.Equal(t, `export type Weight = number;
+
+export type Region = "eu" | "us";
+
+export type Tier = "free" | "pro";
+
+export type Flags = number;
+`, out)
+}
+
+func TestStringerEnums(t *testing.T) {
+	out, types := translate(t, `package pills
+
+import "strconv"
+
+type Pill int
+
+const (
+	Placebo Pill = iota
+	Aspirin
+	Ibuprofen
+	Paracetamol = Ibuprofen
+)
+
+func (p Pill) MarshalText() ([]byte, error) { return []byte(p.String()), nil }
+
+// Code generated by "stringer -type=Pill"; DO NOT EDIT.
+
+const _Pill_name = "PlaceboAspirinIbuprofen"
+
+var _Pill_index = [...]uint8{0, 7, 14, 23}
+
+func (i Pill) String() string {
+	if i < 0 || i >= Pill(len(_Pill_index)-1) {
+		return "Pill(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _Pill_name[_Pill_index[i]:_Pill_index[i+1]]
+}
+
+type Level uint8
+
+const (
+	Low Level = 1
+	High Level = 4
+)
+
+func (l Level) MarshalText() ([]byte, error) { return []byte(l.String()), nil }
+
+const _Level_name = "lowhigh"
+
+var _Level_map = map[Level]string{
+	1: _Level_name[0:3],
+	4: _Level_name[3:7],
+}
+
+func (i Level) String() string { return _Level_map[i] }
+`)
+	assert.Equal(t, `export type Pill = "Placebo" | "Aspirin" | "Ibuprofen";
+
+export type Level = "low" | "high";
+`, out)
+	assert.Empty(t, types.Diagnostics)
+}
+
+func TestTuples(t *testing.T) {
+	out, types := translate(t, `package geo
+
+const dimensions = 3
+
+type Point [dimensions]float64
+
+type Box [2 * (dimensions - 1)]Point
+
+type Matrix [16]float64
+
+type Bytes []byte
+
+type Shape struct {
+	Origin Point      `+"`json:\"origin\"`"+`
+	Hash   [4]byte    `+"`json:\"hash\"`"+`
+	Tags   [2]*string `+"`json:\"tags\"`"+`
+}
+`)
+	assert.Equal(t, `export type Point = [number, number, number];
+
+export type Box = [Point, Point, Point, Point];
+
+export type Matrix = Array<number>;
+
+export type Bytes = string;
+
+export type Shape = {
+  origin: Point,
+  hash: [number, number, number, number],
+  tags: [string, string],
+};
+`, out)
+	assert.Empty(t, types.Diagnostics)
+}
+
+func TestExportConsts(t *testing.T) {
+	src := `package api
+
+type Status string
+
+const StatusActive Status = "active"
+
+const (
+	MaxPageSize     = 100
+	DefaultPageSize = MaxPageSize / 4
+	DefaultRegion   = "us-east-1"
+	Ratio           = 1.5
+	Debug           = false
+	maxRetries      = 3
+	Huge            = 1 << 60
+)
+
+const (
+	KB = 1 << (10 * (iota + 1))
+	MB
+)
+
+//go2flow:export
+const Precision = 1 << 60
+
+// Internal constants
+const (
+	//go2flow:export false
+	Secret = "hunter2"
+	Timeout = 30 * time.Second
+)
+`
+	types := typeutils.NewTranslator(nil)
+	types.Fset = token.NewFileSet()
+	file, err := parser.ParseFile(types.Fset, "consts.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	types.Pkg = typeutils.NewPackage(file)
+
+	h := NewHandler(types)
+	h.ExportConsts = true
+	for _, decl := range file.Decls {
+		h.HandleConstDecl(*decl.(*ast.GenDecl))
+	}
+	assert.Equal(t, `export const MaxPageSize: 100 = 100;
+export const DefaultPageSize: 25 = 25;
+export const DefaultRegion: "us-east-1" = "us-east-1";
+export const Ratio: 1.5 = 1.5;
+export const Debug: false = false;
+
+export const KB: 1024 = 1024;
+export const MB: 1048576 = 1048576;
+`, printFlow(t, h))
+	require.Len(t, types.Diagnostics, 1)
+	assert.Equal(t, "consts.go:23:7: the constant Precision can't be represented exactly by a JS number", types.Diagnostics[0].String())
+}
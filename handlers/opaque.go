@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"go/ast"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/kristiehoward/go2flow/typeutils"
+)
+
+// isOpaque reports whether the type definition is emitted as an opaque type,
+// either marked with //go2flow:opaque or by the Opaque option unless marked
+// with //go2flow:opaque false
+func (h *Handler) isOpaque(ts ast.TypeSpec) bool {
+	directives := typeutils.ParseDirectives(h.typeDoc(ts))
+	args, ok := directives["opaque"]
+	if !ok {
+		return h.Opaque
+	}
+	return args != "false"
+}
+
+// isPrimitive reports whether the type is a string, number or boolean
+func isPrimitive(t *model.TypeExpr) bool {
+	return t.IsPrimitive(model.String, model.Number, model.Boolean)
+}
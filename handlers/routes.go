@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/kristiehoward/go2flow/typeutils"
+)
+
+// Route is an HTTP endpoint served by a handler func, declared by a route
+// directive on the func, e.g.
+//
+//	//go2flow:route POST /products/{id} UpdateProductRequest -> Product
+//
+// The request type is sent as the JSON body, or as the query string for
+// methods without a body. The request and response types may be omitted.
+type Route struct {
+	// Name is the name of the client function, derived from the handler's
+	// name, e.g. updateProduct for UpdateProduct or handleUpdateProduct
+	Name string
+	// Method is the upper case HTTP method
+	Method string
+	// Path is the URL path, with parameters in braces or after a colon, e.g.
+	// /products/{id} or /products/:id
+	Path string
+	// Params are the names of the path parameters in order
+	Params []string
+	// Request is the Go type of the request, if any
+	Request ast.Expr
+	// Response is the Go type of the response body, if any
+	Response ast.Expr
+	// Pos is the position of the route directive
+	Pos token.Pos
+}
+
+// HasBody reports whether the request type is sent as the JSON body rather
+// than as the query string
+func (r Route) HasBody() bool {
+	switch r.Method {
+	case "GET", "HEAD", "DELETE", "OPTIONS":
+		return false
+	}
+	return true
+}
+
+var httpMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true,
+}
+
+// ParseRoute parses the arguments of a route directive:
+// `METHOD /path [Request] [-> Response]`
+func ParseRoute(args string) (Route, error) {
+	var r Route
+	s := strings.SplitN(args, "->", 2)
+	fields := strings.Fields(s[0])
+	if len(fields) < 2 || len(fields) > 3 {
+		return r, fmt.Errorf("expected `METHOD /path [Request] [-> Response]`, got %q", args)
+	}
+
+	r.Method = strings.ToUpper(fields[0])
+	if !httpMethods[r.Method] {
+		return r, fmt.Errorf("unknown HTTP method %s", fields[0])
+	}
+	r.Path = fields[1]
+	if !strings.HasPrefix(r.Path, "/") {
+		return r, fmt.Errorf("path %s doesn't start with /", r.Path)
+	}
+	for _, segment := range strings.Split(r.Path, "/") {
+		if param, ok := model.PathParam(segment); ok {
+			r.Params = append(r.Params, param)
+		}
+	}
+
+	var err error
+	if len(fields) == 3 {
+		if r.Request, err = parser.ParseExpr(fields[2]); err != nil {
+			return r, fmt.Errorf("invalid request type %s", fields[2])
+		}
+	}
+	if len(s) == 2 {
+		if r.Response, err = parser.ParseExpr(strings.TrimSpace(s[1])); err != nil {
+			return r, fmt.Errorf("invalid response type %s", strings.TrimSpace(s[1]))
+		}
+	}
+	return r, nil
+}
+
+// Routes returns the routes declared on the handler funcs of the file, in
+// declaration order. Malformed route directives are reported.
+func Routes(file *ast.File, types *typeutils.Translator) []Route {
+	var routes []Route
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		args, ok := typeutils.ParseDirectives(fd.Doc)["route"]
+		if !ok {
+			continue
+		}
+		r, err := ParseRoute(args)
+		if err != nil {
+			types.Report(fd.Name, "invalid route for %s: %v", fd.Name.Name, err)
+			continue
+		}
+		r.Name = clientFuncName(fd.Name.Name)
+		for _, c := range fd.Doc.List {
+			if strings.HasPrefix(c.Text, "//go2flow:route") {
+				r.Pos = c.Pos()
+			}
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// clientFuncName returns the lower camel case name of the client function for
+// a handler, without a Handle prefix, e.g. getURL for HandleGetURL
+func clientFuncName(name string) string {
+	for _, prefix := range []string{"Handle", "handle"} {
+		rest := strings.TrimPrefix(name, prefix)
+		if rest != name && rest != "" && unicode.IsUpper([]rune(rest)[0]) {
+			name = rest
+		}
+	}
+	runes := []rune(name)
+	// Lower the leading initialism, but not the start of the next word, e.g.
+	// httpGet for HTTPGet
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) || i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// HandleClient adds the routes and services of a client module, translating
+// their request and response types
+func (h *Handler) HandleClient(routes []Route, services []Service) {
+	for _, s := range services {
+		h.handleService(s)
+	}
+	for _, r := range routes {
+		// The types are parsed from the directive, so they're reported at its
+		// position
+		n := len(h.Types.Diagnostics)
+		h.handleRoute(r)
+		for i := n; i < len(h.Types.Diagnostics) && h.Types.Fset != nil; i++ {
+			h.Types.Diagnostics[i].Pos = h.Types.Fset.Position(r.Pos)
+		}
+	}
+}
+
+func (h *Handler) handleRoute(r Route) {
+	route := &model.Route{Name: r.Name, Method: r.Method, Path: r.Path, Query: !r.HasBody()}
+	if r.Request != nil {
+		route.Request = h.Types.TypeOf(r.Request)
+	}
+	if r.Response != nil {
+		route.Response = h.Types.TypeOf(r.Response)
+	}
+	h.File.Routes = append(h.File.Routes, route)
+}
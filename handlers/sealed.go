@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"go/ast"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/kristiehoward/go2flow/typeutils"
+)
+
+// sealedUnion describes a sealed interface: an interface with an unexported
+// marker method, implemented by a closed set of structs in its package
+type sealedUnion struct {
+	// Members are the names of the implementing structs in declaration order
+	Members []string
+	// Unexported are the implementing structs left out of the union, since
+	// their types aren't emitted
+	Unexported []string
+	// TagField is the Go name of the field that discriminates the members, if
+	// every member carries one
+	TagField string
+	// Tags maps each member to the literal type of its tag field
+	Tags map[string]*model.TypeExpr
+}
+
+// markerMethod returns the name of the interface's unexported marker method,
+// i.e. a method without parameters or results
+func markerMethod(it *ast.InterfaceType) (string, bool) {
+	for _, m := range it.Methods.List {
+		fn, ok := m.Type.(*ast.FuncType)
+		if !ok || len(m.Names) == 0 || m.Names[0].IsExported() {
+			continue
+		}
+		if fn.Params.NumFields() == 0 && fn.Results.NumFields() == 0 {
+			return m.Names[0].Name, true
+		}
+	}
+	return "", false
+}
+
+// sealedUnion returns the union for the interface, or false if it isn't sealed
+func (h *Handler) sealedUnion(it *ast.InterfaceType) (*sealedUnion, bool) {
+	pkg := h.Types.Pkg
+	marker, ok := markerMethod(it)
+	if !ok || pkg == nil {
+		return nil, false
+	}
+
+	u := &sealedUnion{Tags: map[string]*model.TypeExpr{}}
+	for _, name := range pkg.TypeNames {
+		_, isStruct := pkg.Types[name].Type.(*ast.StructType)
+		switch {
+		case !isStruct || !pkg.HasMethod(name, marker):
+		case !ast.IsExported(name):
+			u.Unexported = append(u.Unexported, name)
+		default:
+			u.Members = append(u.Members, name)
+		}
+	}
+	h.discriminate(u)
+	return u, true
+}
+
+// discriminate finds the tag field of the union: a field of the same name and
+// named type in every member, set by convention to a constant of that type
+// named after the member, e.g. `Kind ShapeKind` with `ShapeKindCircle`
+func (h *Handler) discriminate(u *sealedUnion) {
+	if len(u.Members) == 0 {
+		return
+	}
+	pkg := h.Types.Pkg
+	first := pkg.Types[u.Members[0]].Type.(*ast.StructType)
+	for _, field := range first.Fields.List {
+		typeIdent, ok := field.Type.(*ast.Ident)
+		if !ok || len(field.Names) != 1 {
+			continue
+		}
+		fieldName := field.Names[0].Name
+
+		tags := map[string]*model.TypeExpr{}
+		for _, member := range u.Members {
+			if !hasField(pkg.Types[member].Type.(*ast.StructType), fieldName, typeIdent.Name) {
+				break
+			}
+			literal, ok := findTagConst(pkg, member, fieldName, typeIdent.Name)
+			if !ok {
+				break
+			}
+			tags[member] = literal
+		}
+		if len(tags) == len(u.Members) {
+			u.TagField = fieldName
+			u.Tags = tags
+			return
+		}
+	}
+}
+
+// hasField reports whether the struct has a field with the given name and type
+func hasField(st *ast.StructType, name, typeName string) bool {
+	for _, field := range st.Fields.List {
+		ident, ok := field.Type.(*ast.Ident)
+		if ok && ident.Name == typeName && len(field.Names) == 1 && field.Names[0].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// findTagConst returns the literal type of the constant of type typeName named
+// after the member, trying `<Type><Member>`, `<Field><Member>`, `<Member><Type>`
+// and `<Member><Field>`
+func findTagConst(pkg *typeutils.Package, member, fieldName, typeName string) (*model.TypeExpr, bool) {
+	for _, name := range []string{typeName + member, fieldName + member, member + typeName, member + fieldName} {
+		if c, ok := pkg.Consts[name]; ok && c.Type == typeName {
+			if value, ok := pkg.ConstValue(name); ok {
+				return model.Literal(typeutils.LiteralValue(value)), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// memberTag returns the tag field and its literal if the named struct is a
+// discriminated member of a sealed union
+func (h *Handler) memberTag(structName string) (field string, literal *model.TypeExpr, ok bool) {
+	pkg := h.Types.Pkg
+	if pkg == nil {
+		return "", nil, false
+	}
+	for _, name := range pkg.TypeNames {
+		it, isInterface := pkg.Types[name].Type.(*ast.InterfaceType)
+		if !isInterface {
+			continue
+		}
+		u, sealed := h.sealedUnion(it)
+		if sealed && u.TagField != "" {
+			if literal, ok := u.Tags[structName]; ok {
+				return u.TagField, literal, true
+			}
+		}
+	}
+	return "", nil, false
+}
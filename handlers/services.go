@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"path"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/kristiehoward/go2flow/typeutils"
+)
+
+// DefaultRPCPrefix is the path prefix of the Twirp URL convention,
+// <prefix>/<package>.<Service>/<Method>
+const DefaultRPCPrefix = "/twirp"
+
+// Service is an RPC service defined as a Go interface marked with a service
+// directive, whose methods take a context and a request and return a response
+// and an error, as Twirp and Connect-style services do:
+//
+//	//go2flow:service acme.catalog.v1.Catalog
+//	type Catalog interface {
+//		GetProduct(context.Context, *GetProductRequest) (*Product, error)
+//	}
+//
+// The fully qualified service name defaults to <Go package>.<Interface>.
+type Service struct {
+	// Name is the name of the interface
+	Name string
+	// PathPrefix is the path of the service's methods, e.g.
+	// /twirp/acme.catalog.v1.Catalog/
+	PathPrefix string
+	// Methods are the service's methods in declaration order
+	Methods []RPC
+}
+
+// RPC is a method of a service
+type RPC struct {
+	Name     string
+	Request  ast.Expr
+	Response ast.Expr
+}
+
+// isService reports whether the type definition is a service interface
+func (h *Handler) isService(ts ast.TypeSpec) bool {
+	return typeutils.ParseDirectives(h.typeDoc(ts)).Has("service")
+}
+
+// Services returns the services defined in the file, in declaration order. The
+// methods of a service that aren't RPCs are reported.
+func Services(file *ast.File, types *typeutils.Translator, prefix string) []Service {
+	if prefix == "" {
+		prefix = DefaultRPCPrefix
+	}
+	var services []Service
+	for _, decl := range file.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range d.Specs {
+			ts := spec.(*ast.TypeSpec)
+			it, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			name, ok := typeutils.ParseDirectives(typeutils.TypeDoc(d, ts))["service"]
+			if !ok {
+				continue
+			}
+			if name == "" {
+				name = file.Name.Name + "." + ts.Name.Name
+			}
+
+			s := Service{Name: ts.Name.Name, PathPrefix: path.Join(prefix, name) + "/"}
+			// Twirp generates the path prefix of its services
+			if value, ok := types.Pkg.ConstValue(ts.Name.Name + "PathPrefix"); ok && value.Kind() == constant.String {
+				s.PathPrefix = constant.StringVal(value)
+			}
+			for _, m := range it.Methods.List {
+				if len(m.Names) == 0 {
+					types.Report(m, "embedded interfaces of service %s aren't supported", ts.Name)
+					continue
+				}
+				rpc, ok := rpcMethod(m)
+				if !ok {
+					types.Report(m, "method %s of service %s doesn't have the signature (context.Context, *Request) (*Response, error)", m.Names[0], ts.Name)
+					continue
+				}
+				s.Methods = append(s.Methods, rpc)
+			}
+			services = append(services, s)
+		}
+	}
+	return services
+}
+
+// rpcMethod returns the RPC of an interface method with the signature
+// (context.Context, *Request) (*Response, error)
+func rpcMethod(m *ast.Field) (RPC, bool) {
+	fn := m.Type.(*ast.FuncType)
+	params, results := fieldTypes(fn.Params), fieldTypes(fn.Results)
+	if len(params) != 2 || len(results) != 2 {
+		return RPC{}, false
+	}
+	ctx, ok := params[0].(*ast.SelectorExpr)
+	if !ok || fmt.Sprintf("%s.%s", ctx.X, ctx.Sel) != "context.Context" {
+		return RPC{}, false
+	}
+	if err, ok := results[1].(*ast.Ident); !ok || err.Name != "error" {
+		return RPC{}, false
+	}
+	return RPC{Name: m.Names[0].Name, Request: params[1], Response: results[0]}, true
+}
+
+// fieldTypes returns the type of each parameter or result in the list, e.g.
+// two for `a, b int`
+func fieldTypes(list *ast.FieldList) []ast.Expr {
+	var types []ast.Expr
+	if list == nil {
+		return types
+	}
+	for _, f := range list.List {
+		types = append(types, f.Type)
+		for i := 1; i < len(f.Names); i++ {
+			types = append(types, f.Type)
+		}
+	}
+	return types
+}
+
+func (h *Handler) handleService(s Service) {
+	service := &model.Service{Name: s.Name, PathPrefix: s.PathPrefix}
+	for _, rpc := range s.Methods {
+		service.Methods = append(service.Methods, &model.RPC{
+			Name:     rpc.Name,
+			Method:   clientFuncName(rpc.Name),
+			Request:  h.Types.TypeOf(rpc.Request),
+			Response: h.Types.TypeOf(rpc.Response),
+		})
+	}
+	h.File.Services = append(h.File.Services, service)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"go/ast"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/kristiehoward/go2flow/typeutils"
+)
+
+// oneOfType returns the literal union of the values allowed by a field's
+// `oneof` rule, which only applies to strings and numbers
+func (h *Handler) oneOfType(f ast.Field, v typeutils.Validation, fieldType *model.TypeExpr) (*model.TypeExpr, bool) {
+	switch h.Types.BoundKind(f.Type) {
+	case typeutils.BoundNumber:
+		if !fieldType.IsPrimitive(model.String) {
+			// Unless the json `string` option encodes the number as a string
+			fieldType = model.Primitive(model.Number)
+		}
+	case typeutils.BoundString:
+		fieldType = model.Primitive(model.String)
+	default:
+		return nil, false
+	}
+	return v.OneOfType(fieldType)
+}
+
+// constraints returns the bounds that a field's validation rules set, nil if
+// they set none
+func (h *Handler) constraints(f ast.Field, v typeutils.Validation) *model.Constraints {
+	return v.Constraints(h.Types.BoundKind(f.Type))
+}
@@ -0,0 +1,229 @@
+package go2flow
+
+import (
+	"go/build"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/kristiehoward/go2flow/typeutils"
+)
+
+// DefaultImportsDir is where the Flow definitions of imported packages are
+// generated when Config.ImportsDir is empty
+const DefaultImportsDir = "go2flow_imports"
+
+// resolveImport reports whether the types of the imported package can be
+// imported in Flow, either from a configured module or by generating them. The
+// packages to generate are queued.
+func (g *generator) resolveImport(pkgPath string) bool {
+	if _, ok := g.cfg.ImportModules[pkgPath]; ok {
+		return true
+	}
+	if !g.cfg.GenerateImports {
+		return false
+	}
+	if dir, ok := g.importDirs[pkgPath]; ok {
+		return dir != ""
+	}
+
+	dir := locatePackage(pkgPath, g.dir)
+	g.importDirs[pkgPath] = dir
+	if dir != "" {
+		g.pending = append(g.pending, pkgPath)
+	}
+	return dir != ""
+}
+
+// generateImport generates the Flow definitions for all files of an imported
+// package into a single module
+func (g *generator) generateImport(pkgPath string) error {
+	dir := g.importDirs[pkgPath]
+	bp, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	for _, name := range bp.GoFiles {
+		files = append(files, filepath.Join(dir, name))
+	}
+	packages, err := parsePackages(g.types.Fset, files)
+	if err != nil || len(packages) == 0 {
+		return err
+	}
+	pkg := packages[0]
+
+	g.types.Pkg = typeutils.NewPackage(pkg.astFiles...)
+	g.dir = dir
+	f := &model.File{Name: g.importOutput(pkgPath), Package: pkgPath, Source: pkgPath}
+	var imported []typeutils.ImportedType
+	seen := map[typeutils.ImportedType]bool{}
+	for _, astFile := range pkg.astFiles {
+		g.types.BeginFile(astFile)
+		f.Decls = append(f.Decls, generateFile(astFile, g.types, g.cfg).Decls...)
+		for _, it := range g.types.ImportedTypes() {
+			if !seen[it] {
+				seen[it] = true
+				imported = append(imported, it)
+			}
+		}
+	}
+	sort.Slice(imported, func(i, j int) bool {
+		if imported[i].PkgPath != imported[j].PkgPath {
+			return imported[i].PkgPath < imported[j].PkgPath
+		}
+		return imported[i].Name < imported[j].Name
+	})
+	f.Imports = g.imports(f.Name, imported)
+	linkImports(f)
+	g.schema.Files = append(g.schema.Files, f)
+	return nil
+}
+
+// importOutput returns the name of the file generated for an imported package
+func (g *generator) importOutput(pkgPath string) string {
+	dir := g.cfg.ImportsDir
+	if dir == "" {
+		dir = DefaultImportsDir
+	}
+	return filepath.Join(dir, filepath.FromSlash(pkgPath)) + ".js"
+}
+
+// imports returns the import declarations of the imported types, sorted by
+// package, for the output file with the given name
+func (g *generator) imports(name string, imported []typeutils.ImportedType) []*model.Import {
+	var imports []*model.Import
+	for i := 0; i < len(imported); {
+		pkgPath := imported[i].PkgPath
+		module, ok := g.cfg.ImportModules[pkgPath]
+		if !ok {
+			module = relativeModule(name, g.importOutput(pkgPath))
+		}
+		imp := &model.Import{Module: module}
+		for ; i < len(imported) && imported[i].PkgPath == pkgPath; i++ {
+			it := imported[i]
+			name := &model.ImportName{Name: it.Name}
+			if it.FlowName != it.Name {
+				name.As = it.FlowName
+			}
+			imp.Names = append(imp.Names, name)
+		}
+		imports = append(imports, imp)
+	}
+	return imports
+}
+
+// relativeModule returns the module specifier for the generated file to,
+// relative to the generated file from
+func relativeModule(from, to string) string {
+	fromDir, _ := filepath.Abs(filepath.Dir(from))
+	to, _ = filepath.Abs(to)
+	rel, err := filepath.Rel(fromDir, to)
+	if err != nil {
+		return filepath.ToSlash(strings.TrimSuffix(to, ".js"))
+	}
+	rel = filepath.ToSlash(strings.TrimSuffix(rel, ".js"))
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+// locatePackage returns the directory holding the source of the imported
+// package, looking in the vendor directories above dir, the GOPATH and the
+// module cache, without touching the network. It returns the empty string if
+// the source isn't available.
+func locatePackage(pkgPath, dir string) string {
+	if dir, err := filepath.Abs(dir); err == nil {
+		for {
+			if candidate := filepath.Join(dir, "vendor", filepath.FromSlash(pkgPath)); isDir(candidate) {
+				return candidate
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+
+	gopaths := filepath.SplitList(build.Default.GOPATH)
+	for _, gopath := range gopaths {
+		if candidate := filepath.Join(gopath, "src", filepath.FromSlash(pkgPath)); isDir(candidate) {
+			return candidate
+		}
+	}
+
+	modCache := os.Getenv("GOMODCACHE")
+	if modCache == "" && len(gopaths) > 0 {
+		modCache = filepath.Join(gopaths[0], "pkg", "mod")
+	}
+	if modCache == "" {
+		return ""
+	}
+	// The module is the longest prefix of the import path in the cache, use
+	// its latest version
+	for modPath := pkgPath; modPath != "." && modPath != "/"; modPath = path.Dir(modPath) {
+		versions, _ := filepath.Glob(filepath.Join(modCache, filepath.FromSlash(escapeModulePath(modPath))) + "@*")
+		if len(versions) == 0 {
+			continue
+		}
+		sort.Slice(versions, func(i, j int) bool {
+			return versionLess(versions[i][strings.LastIndex(versions[i], "@")+1:], versions[j][strings.LastIndex(versions[j], "@")+1:])
+		})
+		candidate := filepath.Join(versions[len(versions)-1], filepath.FromSlash(strings.TrimPrefix(pkgPath, modPath)))
+		if isDir(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func isDir(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && info.IsDir()
+}
+
+// escapeModulePath escapes the upper case letters of a module path the way the
+// module cache does, e.g. github.com/Azure to github.com/!azure
+func escapeModulePath(modPath string) string {
+	var b strings.Builder
+	for _, r := range modPath {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// versionLess compares two module versions such as v1.2.10 and v1.10.0 by their
+// numeric major, minor and patch components
+func versionLess(a, b string) bool {
+	va, vb := versionNumbers(a), versionNumbers(b)
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] < vb[i]
+		}
+	}
+	return a < b
+}
+
+func versionNumbers(v string) [3]int {
+	var n [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	for i, s := range strings.SplitN(v, ".", 3) {
+		n[i], _ = strconv.Atoi(s)
+	}
+	return n
+}
@@ -0,0 +1,259 @@
+// Package jsonschema prints the modules of the model as JSON Schema documents,
+// e.g. to validate request bodies in other languages than JavaScript
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// Draft is the JSON Schema dialect of the printed documents
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Emitter prints the type definitions of each module as the $defs of a JSON
+// Schema document. Constants, routes and services have no schema and are left
+// out.
+type Emitter struct{}
+
+// Emit writes the JSON Schema document of the file
+func (e Emitter) Emit(w io.Writer, f *model.File) error {
+	p := &printer{refs: map[string]string{}}
+	for _, t := range f.Types() {
+		p.refs[t.Name] = "#/$defs/" + t.Name
+	}
+	for _, imp := range f.Imports {
+		for _, name := range imp.Names {
+			local := name.Name
+			if name.As != "" {
+				local = name.As
+			}
+			p.refs[local] = imp.Module + ".schema.json#/$defs/" + name.Name
+		}
+	}
+	defs := schema{}
+	for _, t := range f.Types() {
+		defs = defs.with(t.Name, p.typeDef(t))
+	}
+	doc := schema{{"$schema", Draft}}
+	if len(defs) > 0 {
+		doc = doc.with("$defs", defs)
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(out, '\n'))
+	return err
+}
+
+// FileName returns the name of the file's document, e.g. types.schema.json for
+// types.js
+func (e Emitter) FileName(f *model.File) string {
+	return strings.TrimSuffix(f.Name, ".js") + ".schema.json"
+}
+
+// schema is a JSON Schema object, whose keywords are encoded in order
+type schema []keyword
+
+type keyword struct {
+	name  string
+	value interface{}
+}
+
+func (s schema) with(name string, value interface{}) schema {
+	return append(s, keyword{name, value})
+}
+
+// MarshalJSON encodes the keywords in order
+func (s schema) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range s {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(k.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(k.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type printer struct {
+	// refs are the references of the module's types and of the types it
+	// imports, by the name they have in the module
+	refs map[string]string
+}
+
+func (p *printer) typeDef(t *model.Type) schema {
+	var s schema
+	switch t.Kind {
+	case model.KindStruct:
+		s = p.object(t.Fields, false)
+	case model.KindEnum:
+		values := make([]interface{}, 0, len(t.Values))
+		for _, l := range t.Literals() {
+			values = append(values, l.Value)
+		}
+		s = schema{{"enum", values}}
+	default:
+		s = p.typeExpr(t.Type)
+	}
+	if t.Doc != "" {
+		s = append(schema{{"description", t.Doc}}, s...)
+	}
+	return s
+}
+
+func (p *printer) typeExpr(t *model.TypeExpr) schema {
+	if t == nil {
+		return schema{{"not", schema{}}}
+	}
+	switch t.Kind {
+	case model.TypePrimitive:
+		switch t.Name {
+		case model.String, model.Number, model.Boolean, model.Null:
+			return schema{{"type", t.Name}}
+		case model.Unknown, model.Any:
+			return schema{}
+		}
+		// void and never have no JSON value
+		return schema{{"not", schema{}}}
+	case model.TypeRef:
+		if ref, ok := p.refs[t.Name]; ok {
+			return schema{{"$ref", ref}}
+		}
+		if (t.Name == "$ReadOnly" || t.Name == "$ReadOnlyArray") && len(t.Args) == 1 {
+			if t.Name == "$ReadOnlyArray" {
+				return schema{{"type", "array"}, {"items", p.typeExpr(t.Args[0])}}
+			}
+			return p.typeExpr(t.Args[0])
+		}
+		return schema{{"$comment", "unresolved type " + t.String()}}
+	case model.TypeLiteral:
+		return schema{{"const", t.Value}}
+	case model.TypeArray:
+		return schema{{"type", "array"}, {"items", p.typeExpr(t.Elem)}}
+	case model.TypeTuple:
+		items := make([]schema, len(t.Elems))
+		for i, e := range t.Elems {
+			items[i] = p.typeExpr(e)
+		}
+		return schema{{"type", "array"}, {"prefixItems", items}, {"minItems", len(items)}, {"maxItems", len(items)}}
+	case model.TypeMap:
+		return p.mapOf(t.Key, t.Elem)
+	case model.TypeObject:
+		return p.object(t.Fields, t.Exact)
+	case model.TypeUnion:
+		var values []interface{}
+		members := make([]schema, len(t.Members))
+		for i, m := range t.Members {
+			if m.Kind == model.TypeLiteral {
+				values = append(values, m.Value)
+			}
+			members[i] = p.typeExpr(m)
+		}
+		if len(values) == len(t.Members) {
+			return schema{{"enum", values}}
+		}
+		return schema{{"anyOf", members}}
+	case model.TypeNullable:
+		return nullable(p.typeExpr(t.Elem))
+	}
+	return schema{}
+}
+
+// mapOf returns the schema of an object with keys and values of the types.
+// The keys of a JSON object are strings, so only other key types, e.g. an
+// enum, constrain the property names.
+func (p *printer) mapOf(key, value *model.TypeExpr) schema {
+	s := schema{{"type", "object"}}
+	if !key.IsPrimitive(model.String, model.Number) {
+		s = s.with("propertyNames", p.typeExpr(key))
+	}
+	return s.with("additionalProperties", p.typeExpr(value))
+}
+
+// object returns the schema of a struct's or an object type's fields. The
+// types spread into it are combined with allOf.
+func (p *printer) object(fields []*model.Field, exact bool) schema {
+	s := schema{{"type", "object"}}
+	var properties schema
+	var required []string
+	var spreads []schema
+	for _, f := range fields {
+		switch {
+		case f.Spread:
+			spreads = append(spreads, p.typeExpr(f.Type))
+		case f.Key != nil:
+			indexer := p.mapOf(f.Key, f.Type)
+			s = append(s, indexer[1:]...)
+		default:
+			properties = properties.with(f.Name, p.field(f))
+			if !f.Optional {
+				required = append(required, f.Name)
+			}
+		}
+	}
+	if len(properties) > 0 {
+		s = s.with("properties", properties)
+	}
+	if len(required) > 0 {
+		s = s.with("required", required)
+	}
+	if exact && len(spreads) == 0 {
+		s = s.with("additionalProperties", false)
+	}
+	if len(spreads) == 0 {
+		return s
+	}
+	return schema{{"allOf", append(spreads, s)}}
+}
+
+// constraintKeywords are the keywords of model.Constraints in the order they
+// are printed
+var constraintKeywords = []string{
+	"minimum", "exclusiveMinimum", "maximum", "exclusiveMaximum",
+	"minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties",
+}
+
+func (p *printer) field(f *model.Field) schema {
+	s := p.typeExpr(f.Type)
+	if f.Nullable {
+		s = nullable(s)
+	}
+	if f.Constraints != nil {
+		// The constraints are named after the keywords, which only apply to
+		// the values of their type, e.g. minLength to strings
+		var bounds map[string]json.RawMessage
+		data, _ := json.Marshal(f.Constraints)
+		json.Unmarshal(data, &bounds)
+		for _, name := range constraintKeywords {
+			if bound, ok := bounds[name]; ok {
+				s = s.with(name, bound)
+			}
+		}
+	}
+	if f.Doc != "" {
+		s = append(schema{{"description", f.Doc}}, s...)
+	}
+	return s
+}
+
+// nullable returns the schema that also accepts null
+func nullable(s schema) schema {
+	return schema{{"anyOf", []schema{s, {{"type", "null"}}}}}
+}
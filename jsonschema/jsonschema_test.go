@@ -0,0 +1,72 @@
+package jsonschema
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmit(t *testing.T) {
+	one, sixtyFour := 1, 64
+	zero := 0.0
+	f := &model.File{
+		Name:    "api/types.js",
+		Imports: []*model.Import{{Module: "./money", Names: []*model.ImportName{{Name: "Money", As: "Amount"}}}},
+		Decls: []*model.Decl{
+			{Type: &model.Type{Name: "Status", Kind: model.KindEnum, Values: []*model.EnumValue{
+				{Key: "Active", Value: "active"}, {Key: "Retired", Value: "retired"},
+			}}},
+			{Consts: []*model.Const{{Name: "MaxPageSize", Value: 100}}},
+			{Type: &model.Type{Name: "Base", Kind: model.KindStruct, Fields: []*model.Field{
+				{Name: "id", Type: model.Primitive(model.String)},
+			}}},
+			{Type: &model.Type{Name: "Product", Kind: model.KindStruct, Doc: "Product is an item of the catalog", Fields: []*model.Field{
+				{Spread: true, Type: model.Ref("Base")},
+				{Name: "name", Type: model.Primitive(model.String), Constraints: &model.Constraints{MinLength: &one, MaxLength: &sixtyFour}},
+				{Name: "price", Type: model.Ref("Amount"), Optional: true},
+				{Name: "status", Type: model.Ref("Status"), Nullable: true},
+				{Name: "stock", Type: model.Primitive(model.Number), Constraints: &model.Constraints{Minimum: &zero}},
+				{Key: model.Primitive(model.String), Type: model.Primitive(model.Unknown)},
+			}}},
+			{Type: &model.Type{Name: "Page", Kind: model.KindAlias, Type: model.UnionOf(model.Literal(1), model.Literal("last"), model.Ref("Cursor"))}},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Emitter{}.Emit(&buf, f))
+	assert.JSONEq(t, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$defs": {
+    "Status": {"enum": ["active", "retired"]},
+    "Base": {
+      "type": "object",
+      "properties": {"id": {"type": "string"}},
+      "required": ["id"]
+    },
+    "Product": {
+      "description": "Product is an item of the catalog",
+      "allOf": [
+        {"$ref": "#/$defs/Base"},
+        {
+          "type": "object",
+          "additionalProperties": {},
+          "properties": {
+            "name": {"type": "string", "minLength": 1, "maxLength": 64},
+            "price": {"$ref": "./money.schema.json#/$defs/Money"},
+            "status": {"anyOf": [{"$ref": "#/$defs/Status"}, {"type": "null"}]},
+            "stock": {"type": "number", "minimum": 0}
+          },
+          "required": ["name", "status", "stock"]
+        }
+      ]
+    },
+    "Page": {
+      "anyOf": [{"const": 1}, {"const": "last"}, {"$comment": "unresolved type Cursor"}]
+    }
+  }
+}`, buf.String())
+	assert.Equal(t, "api/types.schema.json", Emitter{}.FileName(f))
+}
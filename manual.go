@@ -0,0 +1,204 @@
+package go2flow
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Markers of the hand-written regions of a generated module, e.g. helper
+// types, which WriteOutputs keeps across regenerations
+const (
+	ManualBegin = "// go2flow:begin manual"
+	ManualEnd   = "// go2flow:end manual"
+)
+
+var (
+	manualBegin = regexp.MustCompile(`^\s*//\s*go2flow:begin manual\s*$`)
+	manualEnd   = regexp.MustCompile(`^\s*//\s*go2flow:end manual\s*$`)
+)
+
+// manualRegion is a hand-written region of a module, with the key of the top
+// level statement it follows, nil at the top of the module
+type manualRegion struct {
+	anchor []byte
+	lines  [][]byte
+}
+
+// WriteOutputs writes the generated modules to their files, creating their
+// directories. The hand-written regions of the existing files, between
+// ManualBegin and ManualEnd lines, are kept: see MergeManual.
+func WriteOutputs(outputs map[string][]byte) error {
+	for _, name := range sortedNames(outputs) {
+		content := outputs[name]
+		existing, err := ioutil.ReadFile(name)
+		switch {
+		case err == nil:
+			if content, err = MergeManual(content, existing); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		case !os.IsNotExist(err):
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(name, content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StaleOutputs returns the names of the outputs whose files are missing or
+// differ from the generated modules, ordered by name. The files are compared
+// with their hand-written regions kept, as WriteOutputs would write them, and
+// without the "Regenerate with:" line of their @generated banner, which names
+// whichever command printed them.
+func StaleOutputs(outputs map[string][]byte) ([]string, error) {
+	var stale []string
+	for _, name := range sortedNames(outputs) {
+		content := outputs[name]
+		existing, err := ioutil.ReadFile(name)
+		switch {
+		case os.IsNotExist(err):
+			stale = append(stale, name)
+			continue
+		case err != nil:
+			return nil, err
+		}
+		if content, err = MergeManual(content, existing); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if !bytes.Equal(regenerateLine.ReplaceAll(content, nil), regenerateLine.ReplaceAll(existing, nil)) {
+			stale = append(stale, name)
+		}
+	}
+	return stale, nil
+}
+
+// regenerateLine matches the line of the command of the @generated banner
+var regenerateLine = regexp.MustCompile(`(?m)^// Regenerate with: .*\n`)
+
+func sortedNames(outputs map[string][]byte) []string {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MergeManual returns the generated module with the hand-written regions of
+// the existing one. Each region is put back after the statement it followed,
+// found by the name it declares, or else by its first line, e.g. `import type {
+// Product } from "./types";`, or at the end of the module when the statement is
+// no longer generated. A region at the top of the module stays before the
+// first statement.
+func MergeManual(generated, existing []byte) ([]byte, error) {
+	regions, err := manualRegions(existing)
+	if err != nil || len(regions) == 0 {
+		return generated, err
+	}
+
+	lines := bytes.SplitAfter(generated, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	// The regions following each statement, by the statement's line index,
+	// with -1 for the top of the module and len(lines) for its end
+	after := map[int][]manualRegion{}
+	for _, r := range regions {
+		at := len(lines)
+		if r.anchor == nil {
+			at = -1
+		}
+		for i, line := range lines {
+			if r.anchor != nil && isStatement(line) && bytes.Equal(statementKey(line), r.anchor) {
+				at = i
+				break
+			}
+		}
+		after[at] = append(after[at], r)
+	}
+
+	var merged [][]byte
+	insert := func(at int) {
+		for _, r := range after[at] {
+			if len(merged) > 0 && len(bytes.TrimSpace(merged[len(merged)-1])) > 0 {
+				merged = append(merged, []byte("\n"))
+			}
+			merged = append(merged, r.lines...)
+			merged = append(merged, []byte("\n"))
+		}
+	}
+	last := -1
+	for i, line := range lines {
+		if isStatement(line) {
+			insert(last)
+			last = i
+		}
+		merged = append(merged, line)
+	}
+	insert(last)
+	insert(len(lines))
+	return append(bytes.TrimRight(bytes.Join(merged, nil), "\n"), '\n'), nil
+}
+
+// manualRegions returns the hand-written regions of a module
+func manualRegions(module []byte) ([]manualRegion, error) {
+	var regions []manualRegion
+	var anchor []byte
+	var region *manualRegion
+	for i, line := range bytes.SplitAfter(module, []byte("\n")) {
+		switch {
+		case region != nil:
+			if !bytes.HasSuffix(line, []byte("\n")) {
+				line = append(line[:len(line):len(line)], '\n')
+			}
+			region.lines = append(region.lines, line)
+			if manualEnd.Match(bytes.TrimRight(line, "\n")) {
+				regions = append(regions, *region)
+				region = nil
+			}
+		case manualBegin.Match(bytes.TrimRight(line, "\n")):
+			region = &manualRegion{anchor: anchor, lines: [][]byte{line}}
+		case manualEnd.Match(bytes.TrimRight(line, "\n")):
+			return nil, fmt.Errorf("line %d: %q without %q", i+1, ManualEnd, ManualBegin)
+		case isStatement(line):
+			anchor = statementKey(line)
+		}
+	}
+	if region != nil {
+		return nil, fmt.Errorf("%q without %q", ManualBegin, ManualEnd)
+	}
+	return regions, nil
+}
+
+var declaration = regexp.MustCompile(`^(?:export\s+)?(?:opaque\s+|async\s+)?(?:type|const|let|var|function|class)\s+([\w$]+)`)
+
+// statementKey returns what identifies the top level statement starting at the
+// line: the name it declares, or else the line
+func statementKey(line []byte) []byte {
+	if m := declaration.FindSubmatch(line); m != nil {
+		return m[1]
+	}
+	return bytes.TrimRight(line, "\n")
+}
+
+// isStatement reports whether a line starts a top level statement of a
+// module, i.e. isn't indented, blank, a comment or the end of a statement
+func isStatement(line []byte) bool {
+	if len(line) == 0 {
+		return false
+	}
+	switch line[0] {
+	case ' ', '\t', '\n', '\r', '/', '*', '}', ')', ']', '|':
+		return false
+	}
+	return true
+}
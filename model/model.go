@@ -0,0 +1,269 @@
+// Package model is the intermediate representation between the analysis of the
+// Go source and the printers of the generated modules: the type definitions,
+// constants, routes and services of each module, independently of their Flow
+// syntax. A Schema can be saved as versioned JSON and printed later.
+package model
+
+import "strings"
+
+// Version is the version of the JSON encoding of a Schema. It's incremented
+// when a change to the model would be misread by an earlier version.
+const Version = 2
+
+// Kind is the kind of a type definition
+type Kind string
+
+const (
+	// KindStruct is an object type with fields
+	KindStruct Kind = "struct"
+	// KindEnum is a union of literal values
+	KindEnum Kind = "enum"
+	// KindUnion is the union of the members of a sealed interface
+	KindUnion Kind = "union"
+	// KindOpaque is an opaque type defined from a primitive
+	KindOpaque Kind = "opaque"
+	// KindAlias is any other type definition, e.g. of an array or a map
+	KindAlias Kind = "alias"
+)
+
+// Schema is the model of the modules generated in one run
+type Schema struct {
+	Version int     `json:"version"`
+	Files   []*File `json:"files"`
+}
+
+// File is a generated module
+type File struct {
+	// Name is the name of the generated file, e.g. types.js
+	Name string `json:"name"`
+	// Package is the import path of the Go package of an imported package's
+	// module, empty for the modules of the translated files
+	Package string `json:"package,omitempty"`
+	// Source is the Go package the module is generated from, named in the
+	// banner of generated modules: the name and directory of the package of a
+	// translated file, e.g. catalog (api/catalog), or the import path of an
+	// imported package
+	Source string `json:"source,omitempty"`
+	// Imports are the types the module imports from other modules
+	Imports []*Import `json:"imports,omitempty"`
+	// Decls are the module's type definitions and constants in declaration
+	// order
+	Decls []*Decl `json:"decls,omitempty"`
+	// Routes are the HTTP endpoints of a client module
+	Routes []*Route `json:"routes,omitempty"`
+	// Services are the RPC services of a client module
+	Services []*Service `json:"services,omitempty"`
+}
+
+// Import is an import declaration of types from another module
+type Import struct {
+	Module string        `json:"module"`
+	Names  []*ImportName `json:"names"`
+}
+
+// ImportName is an imported type, renamed As another name if set
+type ImportName struct {
+	Name string `json:"name"`
+	As   string `json:"as,omitempty"`
+}
+
+// Decl is either a type definition or a constant declaration
+type Decl struct {
+	Type *Type `json:"type,omitempty"`
+	// Consts are the constants of a const declaration
+	Consts []*Const `json:"consts,omitempty"`
+}
+
+// Position is the position of a Go declaration
+type Position struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// Type is a generated type definition
+type Type struct {
+	Name string `json:"name"`
+	Kind Kind   `json:"kind"`
+	// Type is the type of a definition other than a struct or an enum
+	Type *TypeExpr `json:"type,omitempty"`
+	// Fields are the properties of a struct
+	Fields []*Field `json:"fields,omitempty"`
+	// Values are the values of an enum
+	Values []*EnumValue `json:"values,omitempty"`
+	// Runtime is set when the values of an enum are exported at runtime
+	Runtime bool     `json:"runtime,omitempty"`
+	Doc     string   `json:"doc,omitempty"`
+	Pos     Position `json:"pos"`
+}
+
+// Field is a property of a struct, or the indexer or spread type that an
+// inlined field contributes to it
+type Field struct {
+	Name string `json:"name,omitempty"`
+	// Type is the type of the property, without the null of a nullable one
+	Type *TypeExpr `json:"type"`
+	// Optional is set when the property may be omitted
+	Optional bool `json:"optional,omitempty"`
+	// Nullable is set when the property may be null
+	Nullable bool `json:"nullable,omitempty"`
+	// Key is the key type of an indexer, e.g. string for `[string]: number`
+	Key *TypeExpr `json:"key,omitempty"`
+	// Spread is set when the Type's properties are spread into the struct
+	Spread bool `json:"spread,omitempty"`
+	// Constraints are the bounds that the property's validation rules set
+	Constraints *Constraints `json:"constraints,omitempty"`
+	Doc         string       `json:"doc,omitempty"`
+	Pos         Position     `json:"pos"`
+}
+
+// Constraints are the bounds of a property's value, named after the JSON Schema
+// validation keywords: the bounds of a number, and of the length of a string,
+// an array or an object
+type Constraints struct {
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	MinLength        *int     `json:"minLength,omitempty"`
+	MaxLength        *int     `json:"maxLength,omitempty"`
+	MinItems         *int     `json:"minItems,omitempty"`
+	MaxItems         *int     `json:"maxItems,omitempty"`
+	MinProperties    *int     `json:"minProperties,omitempty"`
+	MaxProperties    *int     `json:"maxProperties,omitempty"`
+}
+
+// EnumValue is a constant of an enum type
+type EnumValue struct {
+	// Key is the constant's name without the enum type's name, e.g. Active
+	// for StatusActive
+	Key string `json:"key"`
+	// Value is the constant's value: a string, a number or a boolean
+	Value interface{} `json:"value"`
+	// Label is the constant's doc comment, or its key without one
+	Label string `json:"label"`
+}
+
+// Const is an exported constant
+type Const struct {
+	Name string `json:"name"`
+	// Value is the constant's value: a string, a number or a boolean
+	Value interface{} `json:"value"`
+	Doc   string      `json:"doc,omitempty"`
+	Pos   Position    `json:"pos"`
+}
+
+// Route is an HTTP endpoint of a client module
+type Route struct {
+	// Name is the name of the client function
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	// Path is the URL path, with parameters in braces or after a colon
+	Path string `json:"path"`
+	// Request and Response are the types of the request and the response
+	// body, if any
+	Request  *TypeExpr `json:"request,omitempty"`
+	Response *TypeExpr `json:"response,omitempty"`
+	// Query is set when the request is sent as the query string rather than
+	// as the JSON body
+	Query bool `json:"query,omitempty"`
+}
+
+// PathParam returns the name of the parameter of a path segment such as {id},
+// {path...} or :id
+func PathParam(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return strings.TrimSuffix(segment[1:len(segment)-1], "..."), true
+	}
+	if strings.HasPrefix(segment, ":") && len(segment) > 1 {
+		return segment[1:], true
+	}
+	return "", false
+}
+
+// Service is an RPC service of a client module
+type Service struct {
+	Name string `json:"name"`
+	// PathPrefix is the path of the service's methods
+	PathPrefix string `json:"pathPrefix"`
+	Methods    []*RPC `json:"methods"`
+}
+
+// RPC is a method of a service
+type RPC struct {
+	// Name is the name of the Go method, which its path ends with
+	Name string `json:"name"`
+	// Method is the name of the client method, e.g. getProduct
+	Method   string    `json:"method"`
+	Request  *TypeExpr `json:"request"`
+	Response *TypeExpr `json:"response"`
+}
+
+// ID returns the name that identifies a field within its struct: its property
+// name, `[K]` for an indexer, or `...T` for a spread type
+func (f *Field) ID() string {
+	switch {
+	case f.Key != nil:
+		return "[" + f.Key.String() + "]"
+	case f.Spread:
+		return "..." + f.Type.String()
+	}
+	return f.Name
+}
+
+// Types returns the type definitions of the file
+func (f *File) Types() []*Type {
+	var types []*Type
+	for _, d := range f.Decls {
+		if d.Type != nil {
+			types = append(types, d.Type)
+		}
+	}
+	return types
+}
+
+// Field returns the struct's field with the given ID
+func (t *Type) Field(id string) (*Field, bool) {
+	for _, f := range t.Fields {
+		if f.ID() == id {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// Literals returns the literal types of the distinct values of an enum
+func (t *Type) Literals() []*TypeExpr {
+	var literals []*TypeExpr
+	seen := map[string]bool{}
+	for _, v := range t.Values {
+		literal := Literal(v.Value)
+		if key := literal.String(); !seen[key] {
+			seen[key] = true
+			literals = append(literals, literal)
+		}
+	}
+	return literals
+}
+
+// Walk calls fn for each type expression of the file's type definitions, routes
+// and services, and the ones they're made of
+func (f *File) Walk(fn func(*TypeExpr)) {
+	for _, t := range f.Types() {
+		t.Type.Walk(fn)
+		for _, field := range t.Fields {
+			field.Key.Walk(fn)
+			field.Type.Walk(fn)
+		}
+	}
+	for _, r := range f.Routes {
+		r.Request.Walk(fn)
+		r.Response.Walk(fn)
+	}
+	for _, s := range f.Services {
+		for _, rpc := range s.Methods {
+			rpc.Request.Walk(fn)
+			rpc.Response.Walk(fn)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TypeKind is the kind of a type expression
+type TypeKind string
+
+const (
+	// TypePrimitive is a primitive type such as string, see the primitive
+	// names below
+	TypePrimitive TypeKind = "primitive"
+	// TypeRef is a reference to a named type, with type arguments if it's
+	// generic
+	TypeRef TypeKind = "ref"
+	// TypeLiteral is a string, number or boolean literal type
+	TypeLiteral TypeKind = "literal"
+	// TypeArray is an array of elements of the same type
+	TypeArray TypeKind = "array"
+	// TypeTuple is an array of a fixed number of elements
+	TypeTuple TypeKind = "tuple"
+	// TypeMap is an object with keys of one type and values of another
+	TypeMap TypeKind = "map"
+	// TypeObject is an object type with fields
+	TypeObject TypeKind = "object"
+	// TypeUnion is a union of types
+	TypeUnion TypeKind = "union"
+	// TypeNullable is a type that also accepts null
+	TypeNullable TypeKind = "nullable"
+)
+
+// The names of the primitive types
+const (
+	String  = "string"
+	Number  = "number"
+	Boolean = "boolean"
+	Null    = "null"
+	// Void is the type of an absent value
+	Void = "void"
+	// Unknown accepts any value, which must be refined before it's used
+	Unknown = "unknown"
+	// Any accepts any value and opts out of type checking
+	Any = "any"
+	// Never accepts no value
+	Never = "never"
+)
+
+// TypeExpr is a type expression, e.g. the type of a field. Which fields are set
+// depends on its Kind.
+type TypeExpr struct {
+	Kind TypeKind `json:"kind"`
+	// Name is the name of a primitive or of a referenced type
+	Name string `json:"name,omitempty"`
+	// Module is the module a referenced type is imported from, see
+	// File.Imports, empty for the types of the same module
+	Module string `json:"module,omitempty"`
+	// Args are the type arguments of a referenced generic type
+	Args []*TypeExpr `json:"args,omitempty"`
+	// Value is the value of a literal: a string, a number or a boolean
+	Value interface{} `json:"value,omitempty"`
+	// Elem is the type of the elements of an array, of the values of a map,
+	// or the type that a nullable accepts besides null
+	Elem *TypeExpr `json:"elem,omitempty"`
+	// Key is the type of the keys of a map
+	Key *TypeExpr `json:"key,omitempty"`
+	// Elems are the types of the elements of a tuple
+	Elems []*TypeExpr `json:"elems,omitempty"`
+	// Members are the members of a union
+	Members []*TypeExpr `json:"members,omitempty"`
+	// Fields are the fields of an object type
+	Fields []*Field `json:"fields,omitempty"`
+	// Exact is set when an object type has no other fields than its Fields
+	Exact bool `json:"exact,omitempty"`
+}
+
+// MarshalJSON encodes the type expression, keeping the value of a literal even
+// when it's a zero value such as false
+func (t *TypeExpr) MarshalJSON() ([]byte, error) {
+	type typeExpr TypeExpr
+	if t.Kind != TypeLiteral {
+		return json.Marshal((*typeExpr)(t))
+	}
+	return json.Marshal(struct {
+		*typeExpr
+		Value interface{} `json:"value"`
+	}{(*typeExpr)(t), t.Value})
+}
+
+// Primitive returns the primitive type with the given name
+func Primitive(name string) *TypeExpr {
+	return &TypeExpr{Kind: TypePrimitive, Name: name}
+}
+
+// Ref returns a reference to the named type
+func Ref(name string, args ...*TypeExpr) *TypeExpr {
+	return &TypeExpr{Kind: TypeRef, Name: name, Args: args}
+}
+
+// Literal returns the literal type of a value
+func Literal(value interface{}) *TypeExpr {
+	return &TypeExpr{Kind: TypeLiteral, Value: value}
+}
+
+// ArrayOf returns the array type of the elements' type
+func ArrayOf(elem *TypeExpr) *TypeExpr {
+	return &TypeExpr{Kind: TypeArray, Elem: elem}
+}
+
+// TupleOf returns the tuple type of the elements' types
+func TupleOf(elems ...*TypeExpr) *TypeExpr {
+	return &TypeExpr{Kind: TypeTuple, Elems: elems}
+}
+
+// MapOf returns the map type of the keys' and values' types
+func MapOf(key, value *TypeExpr) *TypeExpr {
+	return &TypeExpr{Kind: TypeMap, Key: key, Elem: value}
+}
+
+// UnionOf returns the union of the members, or the member itself if there's
+// only one
+func UnionOf(members ...*TypeExpr) *TypeExpr {
+	if len(members) == 1 {
+		return members[0]
+	}
+	return &TypeExpr{Kind: TypeUnion, Members: members}
+}
+
+// NullableOf returns the type that accepts null besides the values of t, which
+// is t itself if it's already nullable
+func NullableOf(t *TypeExpr) *TypeExpr {
+	if t.Kind == TypeNullable {
+		return t
+	}
+	return &TypeExpr{Kind: TypeNullable, Elem: t}
+}
+
+// IsPrimitive reports whether the type is one of the named primitives
+func (t *TypeExpr) IsPrimitive(names ...string) bool {
+	if t == nil || t.Kind != TypePrimitive {
+		return false
+	}
+	for _, name := range names {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a deep copy of the type expression
+func (t *TypeExpr) Clone() *TypeExpr {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	c.Args, c.Elems, c.Members = cloneList(t.Args), cloneList(t.Elems), cloneList(t.Members)
+	c.Key, c.Elem = t.Key.Clone(), t.Elem.Clone()
+	c.Fields = nil
+	for _, f := range t.Fields {
+		field := *f
+		field.Key, field.Type = f.Key.Clone(), f.Type.Clone()
+		c.Fields = append(c.Fields, &field)
+	}
+	return &c
+}
+
+func cloneList(types []*TypeExpr) []*TypeExpr {
+	if types == nil {
+		return nil
+	}
+	clones := make([]*TypeExpr, len(types))
+	for i, t := range types {
+		clones[i] = t.Clone()
+	}
+	return clones
+}
+
+// Walk calls fn for the type expression and the ones it's made of, depth first
+func (t *TypeExpr) Walk(fn func(*TypeExpr)) {
+	if t == nil {
+		return
+	}
+	fn(t)
+	for _, list := range [][]*TypeExpr{t.Args, t.Elems, t.Members} {
+		for _, e := range list {
+			e.Walk(fn)
+		}
+	}
+	t.Key.Walk(fn)
+	t.Elem.Walk(fn)
+	for _, f := range t.Fields {
+		f.Key.Walk(fn)
+		f.Type.Walk(fn)
+	}
+}
+
+// String returns a compact notation of the type that identifies it in field
+// IDs, e.g. Array<Product> or {[string]: number}. Printers lay types out in the
+// syntax of their language.
+func (t *TypeExpr) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case TypePrimitive:
+		return t.Name
+	case TypeRef:
+		if len(t.Args) == 0 {
+			return t.Name
+		}
+		return t.Name + "<" + typeList(t.Args, ", ") + ">"
+	case TypeLiteral:
+		if s, ok := t.Value.(string); ok {
+			return strconv.Quote(s)
+		}
+		return fmt.Sprint(t.Value)
+	case TypeArray:
+		return "Array<" + t.Elem.String() + ">"
+	case TypeTuple:
+		return "[" + typeList(t.Elems, ", ") + "]"
+	case TypeMap:
+		return "{[" + t.Key.String() + "]: " + t.Elem.String() + "}"
+	case TypeObject:
+		fields := make([]string, len(t.Fields))
+		for i, f := range t.Fields {
+			fields[i] = f.ID() + ": " + f.Type.String()
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case TypeUnion:
+		return typeList(t.Members, " | ")
+	case TypeNullable:
+		return "?" + t.Elem.String()
+	}
+	return string(t.Kind)
+}
+
+func typeList(types []*TypeExpr, sep string) string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = t.String()
+	}
+	return strings.Join(s, sep)
+}
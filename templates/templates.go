@@ -0,0 +1,227 @@
+// Package templates prints the modules of the model through text/template, for
+// custom output formats that don't warrant an Emitter written in Go, e.g. a
+// Markdown table of the fields of each type.
+package templates
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/kristiehoward/go2flow/flow"
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// Emitter executes a template for each module, with the module's *model.File
+// as dot
+type Emitter struct {
+	// Ext replaces the .js extension of the modules' file names if set, e.g.
+	// .md for Markdown
+	Ext string
+
+	tmpl *template.Template
+}
+
+// Parse returns the Emitter of the template text, which can call the Funcs
+func Parse(name, text string) (*Emitter, error) {
+	tmpl, err := template.New(name).Funcs(Funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Emitter{tmpl: tmpl}, nil
+}
+
+// ParseFile returns the Emitter of the template file
+func ParseFile(path string) (*Emitter, error) {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(filepath.Base(path), string(text))
+}
+
+// Emit executes the template for the file
+func (e *Emitter) Emit(w io.Writer, f *model.File) error {
+	return e.tmpl.Execute(w, f)
+}
+
+// FileName returns the name of the file's module, with the Ext of the emitter
+func (e *Emitter) FileName(f *model.File) string {
+	if e.Ext == "" {
+		return f.Name
+	}
+	return strings.TrimSuffix(f.Name, filepath.Ext(f.Name)) + e.Ext
+}
+
+// Funcs are the helpers available to the templates:
+//
+//	flowType  the Flow type of a *model.Field, including the ? of a nullable
+//	          one, of a *model.Type, e.g. "active" | "retired" for an enum, or
+//	          of a *model.TypeExpr
+//	optional  whether a *model.Field may be omitted
+//	nullable  whether a *model.Field may be null
+//	doc       the doc comment of a *model.Type, *model.Field or *model.Const
+//	comment   prefixes each line of a text, e.g. {{comment "# " (doc .)}}
+//	camel     camelCase, e.g. productId for ProductID
+//	pascal    PascalCase, e.g. ProductId for product_id
+//	snake     snake_case, e.g. product_id for ProductID
+//	kebab     kebab-case, e.g. product-id for ProductID
+//	upper     UPPER_SNAKE_CASE, e.g. PRODUCT_ID for ProductID
+//	lower     lower case
+//	quote     a double quoted string literal
+//	join      joins strings, or the names of *model.ImportName, *model.Type,
+//	          *model.Field or *model.Const values, with a separator, e.g.
+//	          {{range .Imports}}{{join .Names ", "}}{{end}}
+var Funcs = template.FuncMap{
+	"flowType": flowType,
+	"optional": func(f *model.Field) bool { return f.Optional },
+	"nullable": func(f *model.Field) bool { return f.Nullable },
+	"doc":      doc,
+	"comment":  comment,
+	"camel":    camel,
+	"pascal":   pascal,
+	"snake":    func(s string) string { return strings.ToLower(strings.Join(words(s), "_")) },
+	"kebab":    func(s string) string { return strings.ToLower(strings.Join(words(s), "-")) },
+	"upper":    func(s string) string { return strings.ToUpper(strings.Join(words(s), "_")) },
+	"lower":    strings.ToLower,
+	"quote":    strconv.Quote,
+	"join":     join,
+}
+
+func join(elems interface{}, sep string) (string, error) {
+	var names []string
+	switch elems := elems.(type) {
+	case []string:
+		names = elems
+	case []*model.ImportName:
+		for _, e := range elems {
+			names = append(names, e.Name)
+		}
+	case []*model.Type:
+		for _, e := range elems {
+			names = append(names, e.Name)
+		}
+	case []*model.Field:
+		for _, e := range elems {
+			names = append(names, e.Name)
+		}
+	case []*model.Const:
+		for _, e := range elems {
+			names = append(names, e.Name)
+		}
+	default:
+		return "", fmt.Errorf("join of %T, expected strings or named values of the model", elems)
+	}
+	return strings.Join(names, sep), nil
+}
+
+func flowType(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case *model.Field:
+		if v.Nullable {
+			return flow.TypeString(model.NullableOf(v.Type)), nil
+		}
+		return flow.TypeString(v.Type), nil
+	case *model.Type:
+		switch v.Kind {
+		case model.KindEnum:
+			return flow.TypeString(model.UnionOf(v.Literals()...)), nil
+		case model.KindStruct:
+			return v.Name, nil
+		}
+		return flow.TypeString(v.Type), nil
+	case *model.TypeExpr:
+		return flow.TypeString(v), nil
+	}
+	return "", fmt.Errorf("flowType of %T, expected a *model.Field, *model.Type or *model.TypeExpr", v)
+}
+
+func doc(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case *model.Type:
+		return v.Doc, nil
+	case *model.Field:
+		return v.Doc, nil
+	case *model.Const:
+		return v.Doc, nil
+	}
+	return "", fmt.Errorf("doc of %T, expected a *model.Type, *model.Field or *model.Const", v)
+}
+
+// comment prefixes each line of the text, which is empty without a text
+func comment(prefix, text string) string {
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(prefix+line, unicode.IsSpace)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func camel(s string) string {
+	w := words(s)
+	for i := range w {
+		w[i] = strings.ToLower(w[i])
+		if i > 0 {
+			w[i] = title(w[i])
+		}
+	}
+	return strings.Join(w, "")
+}
+
+func pascal(s string) string {
+	w := words(s)
+	for i := range w {
+		w[i] = title(strings.ToLower(w[i]))
+	}
+	return strings.Join(w, "")
+}
+
+func title(word string) string {
+	runes := []rune(word)
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
+
+// words splits an identifier into its words, at underscores, hyphens, spaces
+// and case changes, keeping initialisms whole, e.g. [Product ID] for ProductID
+// and [HTTP Server] for HTTPServer
+func words(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := runes[i-1]
+		// A word starts at an upper case letter after a lower case one, or at
+		// the last upper case letter of an initialism followed by lower case
+		if unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+			unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,69 @@
+package templates
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kristiehoward/go2flow/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaseConversion(t *testing.T) {
+	for _, c := range []struct{ in, camel, pascal, snake, kebab, upper string }{
+		{"ProductID", "productId", "ProductId", "product_id", "product-id", "PRODUCT_ID"},
+		{"product_id", "productId", "ProductId", "product_id", "product-id", "PRODUCT_ID"},
+		{"HTTPServer", "httpServer", "HttpServer", "http_server", "http-server", "HTTP_SERVER"},
+		{"createdAt", "createdAt", "CreatedAt", "created_at", "created-at", "CREATED_AT"},
+		{"v2Name", "v2Name", "V2Name", "v2_name", "v2-name", "V2_NAME"},
+	} {
+		assert.Equal(t, c.camel, camel(c.in), c.in)
+		assert.Equal(t, c.pascal, pascal(c.in), c.in)
+		assert.Equal(t, c.snake, Funcs["snake"].(func(string) string)(c.in), c.in)
+		assert.Equal(t, c.kebab, Funcs["kebab"].(func(string) string)(c.in), c.in)
+		assert.Equal(t, c.upper, Funcs["upper"].(func(string) string)(c.in), c.in)
+	}
+}
+
+func TestEmit(t *testing.T) {
+	e, err := Parse("typeddict", `{{range .Types}}{{if eq .Kind "struct"}}class {{.Name}}(TypedDict{{if .Fields}}, total=False{{end}}):
+{{comment "    # " (doc .)}}
+{{range .Fields}}    {{snake .Name}}: {{quote (flowType .)}}{{if not (optional .)}}  # required{{end}}
+{{end}}{{else}}{{upper .Name}} = {{quote (flowType .)}}
+{{end}}{{end}}`)
+	require.NoError(t, err)
+
+	f := &model.File{Name: "types.js", Decls: []*model.Decl{
+		{Type: &model.Type{Name: "Status", Kind: model.KindEnum, Values: []*model.EnumValue{
+			{Key: "Active", Value: "active"}, {Key: "Retired", Value: "retired"},
+		}}},
+		{Consts: []*model.Const{{Name: "MaxPageSize", Value: 100}}},
+		{Type: &model.Type{Name: "Product", Kind: model.KindStruct, Doc: "Product is an item of the catalog\nwith a price", Fields: []*model.Field{
+			{Name: "productID", Type: model.Primitive(model.String)},
+			{Name: "note", Type: model.Primitive(model.String), Optional: true, Nullable: true},
+		}}},
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, e.Emit(&buf, f))
+	assert.Equal(t, `STATUS = "\"active\" | \"retired\""
+class Product(TypedDict, total=False):
+    # Product is an item of the catalog
+    # with a price
+    product_id: "string"  # required
+    note: "?string"
+`, buf.String())
+
+	e, err = Parse("imports", `{{range .Imports}}import { {{join .Names ", "}} } from {{quote .Module}}
+{{end}}{{join .Types " "}}`)
+	require.NoError(t, err)
+	e.Ext = ".md"
+	f.Imports = []*model.Import{{Module: "./money", Names: []*model.ImportName{{Name: "Money"}, {Name: "Currency"}}}}
+	buf.Reset()
+	require.NoError(t, e.Emit(&buf, f))
+	assert.Equal(t, "import { Money, Currency } from \"./money\"\nStatus Product", buf.String())
+	assert.Equal(t, "types.md", e.FileName(f))
+
+	e, err = Parse("invalid", `{{flowType .Name}}`)
+	require.NoError(t, err)
+	assert.Error(t, e.Emit(&buf, f))
+}
@@ -0,0 +1,103 @@
+package typeutils
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"strconv"
+)
+
+// ConstValue evaluates the value of the package level constant, including iota
+// and arithmetic on other constants of the package. ok is false when the value
+// can't be evaluated from the package's source alone.
+func (p *Package) ConstValue(name string) (value constant.Value, ok bool) {
+	return p.constValue(name, map[string]bool{})
+}
+
+func (p *Package) constValue(name string, seen map[string]bool) (constant.Value, bool) {
+	c, ok := p.Consts[name]
+	if !ok || c.Value == nil || seen[name] {
+		return nil, false
+	}
+	seen[name] = true
+	defer delete(seen, name)
+	return p.eval(c.Value, c.Iota, seen)
+}
+
+// eval evaluates a constant expression of a const spec with the given iota
+func (p *Package) eval(expr ast.Expr, iota int, seen map[string]bool) (constant.Value, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(e.Value, e.Kind, 0)
+		return v, v.Kind() != constant.Unknown
+	case *ast.ParenExpr:
+		return p.eval(e.X, iota, seen)
+	case *ast.Ident:
+		switch e.Name {
+		case "iota":
+			return constant.MakeInt64(int64(iota)), true
+		case "true":
+			return constant.MakeBool(true), true
+		case "false":
+			return constant.MakeBool(false), true
+		}
+		return p.constValue(e.Name, seen)
+	case *ast.UnaryExpr:
+		x, ok := p.eval(e.X, iota, seen)
+		if !ok {
+			return nil, false
+		}
+		return constant.UnaryOp(e.Op, x, 0), true
+	case *ast.BinaryExpr:
+		x, ok := p.eval(e.X, iota, seen)
+		if !ok {
+			return nil, false
+		}
+		y, ok := p.eval(e.Y, iota, seen)
+		if !ok {
+			return nil, false
+		}
+		op := e.Op
+		switch op {
+		case token.SHL, token.SHR:
+			s, ok := constant.Uint64Val(y)
+			if !ok {
+				return nil, false
+			}
+			return constant.Shift(x, op, uint(s)), true
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(x, op, y)), true
+		case token.QUO, token.REM:
+			if y.Kind() != constant.Int && y.Kind() != constant.Float || constant.Sign(y) == 0 {
+				return nil, false
+			}
+			// Integer division truncates
+			if op == token.QUO && x.Kind() == constant.Int && y.Kind() == constant.Int {
+				op = token.QUO_ASSIGN
+			}
+		}
+		return constant.BinaryOp(x, op, y), true
+	case *ast.CallExpr:
+		// Conversions such as Status("active") or float64(1) keep the value
+		if len(e.Args) == 1 {
+			return p.eval(e.Args[0], iota, seen)
+		}
+	}
+	return nil, false
+}
+
+// LiteralValue returns the value of a constant for the model: a string, a
+// boolean, or a json.Number that keeps an integer exact
+func LiteralValue(v constant.Value) interface{} {
+	switch v.Kind() {
+	case constant.String:
+		return constant.StringVal(v)
+	case constant.Bool:
+		return constant.BoolVal(v)
+	case constant.Float:
+		f, _ := constant.Float64Val(v)
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return json.Number(v.ExactString())
+}
@@ -0,0 +1,70 @@
+package typeutils
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// Severity tells apart the constructs that could not be translated from the
+// ones that only deserve attention
+type Severity int
+
+const (
+	// SeverityError is a construct that could not be translated, which is
+	// left out or generated as a placeholder such as MISSING_TYPE_DEF_IN_MAP
+	SeverityError Severity = iota
+	// SeverityWarning is a construct that was translated, or deliberately
+	// left out, but may not be what was intended
+	SeverityWarning
+)
+
+// Diagnostic describes a Go construct that could not be translated, or that
+// was translated with a warning, at its position in the source
+type Diagnostic struct {
+	Pos      token.Position
+	Message  string
+	Severity Severity
+}
+
+func (d Diagnostic) String() string {
+	message := d.Message
+	if d.Severity == SeverityWarning {
+		message = "warning: " + message
+	}
+	if !d.Pos.IsValid() {
+		return message
+	}
+	return fmt.Sprintf("%s: %s", d.Pos, message)
+}
+
+// Errors returns the diagnostics of the constructs that could not be
+// translated, leaving out the warnings
+func Errors(diagnostics []Diagnostic) []Diagnostic {
+	var errors []Diagnostic
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			errors = append(errors, d)
+		}
+	}
+	return errors
+}
+
+// Report records an error for node. Positions are resolved through the
+// translator's Fset when it has one.
+func (tr *Translator) Report(node ast.Node, format string, args ...interface{}) {
+	tr.report(node, SeverityError, format, args...)
+}
+
+// Warn records a warning for node
+func (tr *Translator) Warn(node ast.Node, format string, args ...interface{}) {
+	tr.report(node, SeverityWarning, format, args...)
+}
+
+func (tr *Translator) report(node ast.Node, severity Severity, format string, args ...interface{}) {
+	d := Diagnostic{Message: fmt.Sprintf(format, args...), Severity: severity}
+	if tr.Fset != nil && node != nil {
+		d.Pos = tr.Fset.Position(node.Pos())
+	}
+	tr.Diagnostics = append(tr.Diagnostics, d)
+}
@@ -0,0 +1,101 @@
+package typeutils
+
+import (
+	"go/ast"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ImportedType is a type of another Go package referenced by the file being
+// translated, which the generated Flow module imports
+type ImportedType struct {
+	// PkgPath is the Go import path of the type's package
+	PkgPath string
+	// Name is the name of the Go type
+	Name string
+	// FlowName is the name the type is imported as, which is prefixed with its
+	// package name if Name collides with another type in the file
+	FlowName string
+}
+
+// BeginFile prepares the translator to translate the types of file, resolving
+// the package names of its imports to their import paths
+func (tr *Translator) BeginFile(file *ast.File) {
+	tr.Imports = map[string]string{}
+	tr.imported = nil
+	for _, spec := range file.Imports {
+		names, pkgPath := ImportNames(spec)
+		for _, name := range names {
+			if name != "_" && name != "." {
+				tr.Imports[name] = pkgPath
+			}
+		}
+	}
+}
+
+// ImportedTypes returns the imported types referenced since BeginFile, sorted
+// by package and name
+func (tr *Translator) ImportedTypes() []ImportedType {
+	imported := append([]ImportedType(nil), tr.imported...)
+	sort.Slice(imported, func(i, j int) bool {
+		if imported[i].PkgPath != imported[j].PkgPath {
+			return imported[i].PkgPath < imported[j].PkgPath
+		}
+		return imported[i].Name < imported[j].Name
+	})
+	return imported
+}
+
+// importType records a reference to an imported type and returns its Flow name
+func (tr *Translator) importType(pkgPath, pkgName, name string) string {
+	taken := tr.Pkg != nil && tr.Pkg.Types[name] != nil
+	for _, it := range tr.imported {
+		if it.PkgPath == pkgPath && it.Name == name {
+			return it.FlowName
+		}
+		taken = taken || it.FlowName == name
+	}
+
+	flowName := name
+	if taken {
+		flowName = strings.ToUpper(pkgName[:1]) + pkgName[1:] + name
+	}
+	tr.imported = append(tr.imported, ImportedType{PkgPath: pkgPath, Name: name, FlowName: flowName})
+	return flowName
+}
+
+// ImportNames returns the name a file refers to an imported package by, and the
+// package's import path. Without an explicit name, the package's name isn't
+// known without its source, so the names it is conventionally given are
+// returned, e.g. `yaml` for gopkg.in/yaml.v2, `v1` and `meta` for
+// k8s.io/apimachinery/pkg/apis/meta/v1 or `uuid` for github.com/satori/go.uuid.
+func ImportNames(spec *ast.ImportSpec) (names []string, pkgPath string) {
+	pkgPath, _ = strconv.Unquote(spec.Path.Value)
+	if spec.Name != nil {
+		return []string{spec.Name.Name}, pkgPath
+	}
+
+	base := path.Base(pkgPath)
+	names = append(names, base)
+	// Major version suffixes, e.g. gopkg.in/yaml.v2 or github.com/org/pkg/v2
+	if i := strings.Index(base, ".v"); i > 0 {
+		names = append(names, base[:i])
+	}
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		names = append(names, path.Base(path.Dir(pkgPath)))
+	}
+	// Repository naming conventions, e.g. go-uuid, go.uuid or uuid-go
+	for _, affix := range []string{"go-", "go."} {
+		if strings.HasPrefix(base, affix) {
+			names = append(names, strings.TrimPrefix(base, affix))
+		}
+	}
+	for _, affix := range []string{"-go", ".go"} {
+		if strings.HasSuffix(base, affix) {
+			names = append(names, strings.TrimSuffix(base, affix))
+		}
+	}
+	return names, pkgPath
+}
@@ -0,0 +1,73 @@
+package typeutils
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// OmitsEmptyV2 reports whether encoding/json/v2's `omitempty` can omit a value of
+// the type. Unlike v1, it omits the values that encode as null, "", {} or [], so
+// it never omits false or 0, but may omit a struct whose fields are all omitted.
+func (tr *Translator) OmitsEmptyV2(expr ast.Expr) bool {
+	switch t := tr.Underlying(expr).(type) {
+	case *ast.Ident:
+		return t.Name == "string" || t.Name == "any"
+	case *ast.ArrayType:
+		// [N]T is only empty when N is 0
+		return t.Len == nil
+	case *ast.SelectorExpr:
+		// time.Time encodes as a non-empty string
+		return fmt.Sprintf("%s.%s", t.X, t.Sel) != "time.Time"
+	}
+	return true
+}
+
+// FormatType returns the type of a value of the type encoded with a json/v2
+// `format:` tag option, or false if the format doesn't change the type. As with
+// TypeOf, a pointer's nullability is left to the caller.
+func (tr *Translator) FormatType(expr ast.Expr, format string) (*model.TypeExpr, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	primitive := ""
+	switch t := tr.Underlying(expr).(type) {
+	case *ast.SelectorExpr:
+		switch fmt.Sprintf("%s.%s", t.X, t.Sel) {
+		case "time.Time":
+			primitive = model.String
+			if strings.HasPrefix(format, "unix") {
+				primitive = model.Number
+			}
+		case "time.Duration":
+			primitive = model.String
+			switch format {
+			case "sec", "milli", "micro", "nano":
+				primitive = model.Number
+			}
+		}
+	case *ast.ArrayType:
+		if IsByteSlice(t) {
+			if format == "array" {
+				return model.ArrayOf(model.Primitive(model.Number)), true
+			}
+			primitive = model.String
+		} else if t.Len == nil && format == "emitnull" {
+			return model.NullableOf(tr.TypeOf(t)), true
+		}
+	case *ast.MapType:
+		if format == "emitnull" {
+			return model.NullableOf(tr.TypeOf(t)), true
+		}
+	case *ast.Ident:
+		if (t.Name == "float32" || t.Name == "float64") && format == "nonfinite" {
+			return model.UnionOf(model.Primitive(model.Number), model.Literal("NaN"), model.Literal("Infinity"), model.Literal("-Infinity")), true
+		}
+	}
+	if primitive == "" {
+		return nil, false
+	}
+	return model.Primitive(primitive), true
+}
@@ -0,0 +1,114 @@
+package typeutils
+
+import (
+	"encoding/json"
+	"go/ast"
+	"strconv"
+	"strings"
+
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// Markers are the `// +name=value` comment markers of a declaration, as read by
+// the Kubernetes code generators and kubebuilder. Markers without a value map
+// to the empty string.
+type Markers map[string]string
+
+// ParseMarkers returns the markers in the comment groups of a declaration
+func ParseMarkers(groups ...*ast.CommentGroup) Markers {
+	markers := Markers{}
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, line := range strings.Split(group.Text(), "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "+") {
+				continue
+			}
+			s := strings.SplitN(strings.TrimPrefix(line, "+"), "=", 2)
+			value := ""
+			if len(s) == 2 {
+				value = s[1]
+			}
+			markers[s[0]] = value
+		}
+	}
+	return markers
+}
+
+// Has reports whether the marker is present
+func (m Markers) Has(name string) bool {
+	_, ok := m[name]
+	return ok
+}
+
+// IsOptional reports whether the field is marked optional with `+optional` or
+// `+kubebuilder:validation:Optional`
+func (m Markers) IsOptional() bool {
+	return m.Has("optional") || m.Has("kubebuilder:validation:Optional")
+}
+
+// IsExcluded reports whether the type is excluded from the API with
+// `+k8s:openapi-gen=false`
+func (m Markers) IsExcluded() bool {
+	return m["k8s:openapi-gen"] == "false"
+}
+
+// Enum returns the literal union of the values of a
+// `+kubebuilder:validation:Enum=A;B` marker. Values are string literals unless
+// the type of the values is number.
+func (m Markers) Enum(valueType *model.TypeExpr) (*model.TypeExpr, bool) {
+	value, ok := m["kubebuilder:validation:Enum"]
+	if !ok || value == "" {
+		return nil, false
+	}
+
+	var literals []*model.TypeExpr
+	for _, v := range strings.Split(value, ";") {
+		v = strings.TrimSpace(v)
+		if unquoted, err := strconv.Unquote(v); err == nil {
+			v = unquoted
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil && valueType.IsPrimitive(model.Number) {
+			literals = append(literals, model.Literal(json.Number(v)))
+		} else {
+			literals = append(literals, model.Literal(v))
+		}
+	}
+	return model.UnionOf(literals...), true
+}
+
+// Directives are the `//go2flow:name args` comment directives of a declaration.
+// Directives without arguments map to the empty string.
+type Directives map[string]string
+
+// ParseDirectives returns the go2flow directives in the comment groups of a
+// declaration. Like other Go directives they have no space after the slashes,
+// so they're read from the raw comments.
+func ParseDirectives(groups ...*ast.CommentGroup) Directives {
+	directives := Directives{}
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, c := range group.List {
+			if !strings.HasPrefix(c.Text, "//go2flow:") {
+				continue
+			}
+			s := strings.SplitN(strings.TrimPrefix(c.Text, "//go2flow:"), " ", 2)
+			args := ""
+			if len(s) == 2 {
+				args = strings.TrimSpace(s[1])
+			}
+			directives[strings.TrimSpace(s[0])] = args
+		}
+	}
+	return directives
+}
+
+// Has reports whether the directive is present
+func (d Directives) Has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
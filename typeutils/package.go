@@ -0,0 +1,170 @@
+package typeutils
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// Package indexes the declarations of a Go package that translating one of its
+// type definitions can depend on, across all of the package's files
+type Package struct {
+	// Types holds the type definitions by name
+	Types map[string]*ast.TypeSpec
+	// TypeNames holds the names of the type definitions in declaration order
+	TypeNames []string
+	// TypeDocs holds the doc comments of the type definitions by name, which
+	// for a single definition are those of its declaration
+	TypeDocs map[string]*ast.CommentGroup
+	// Methods holds the method declarations by receiver type name
+	Methods map[string][]*ast.FuncDecl
+	// Consts holds the constant declarations by name
+	Consts map[string]*Const
+	// ConstNames holds the names of the constants in declaration order
+	ConstNames []string
+	// Vars holds the value expressions of the package level variables by name
+	Vars map[string]ast.Expr
+}
+
+// Const is a package level constant declaration
+type Const struct {
+	Name string
+	// Type is the name of the constant's declared type, empty if untyped
+	Type string
+	// Value is the constant's value expression, repeated from the previous
+	// spec of its const block when omitted
+	Value ast.Expr
+	// Iota is the constant's index in its const block
+	Iota int
+	Doc  *ast.CommentGroup
+	// Spec is the declaring value spec
+	Spec *ast.ValueSpec
+}
+
+// NewPackage indexes the declarations of the given files of one package
+func NewPackage(files ...*ast.File) *Package {
+	p := &Package{
+		Types:    map[string]*ast.TypeSpec{},
+		TypeDocs: map[string]*ast.CommentGroup{},
+		Methods:  map[string][]*ast.FuncDecl{},
+		Consts:   map[string]*Const{},
+		Vars:     map[string]ast.Expr{},
+	}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if recv := ReceiverName(d); recv != "" {
+					p.Methods[recv] = append(p.Methods[recv], d)
+				}
+			case *ast.GenDecl:
+				p.addGenDecl(d)
+			}
+		}
+	}
+	return p
+}
+
+func (p *Package) addGenDecl(d *ast.GenDecl) {
+	switch d.Tok {
+	case token.TYPE:
+		for _, spec := range d.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if _, ok := p.Types[ts.Name.Name]; !ok {
+				p.TypeNames = append(p.TypeNames, ts.Name.Name)
+			}
+			p.Types[ts.Name.Name] = ts
+			p.TypeDocs[ts.Name.Name] = TypeDoc(d, ts)
+		}
+	case token.VAR:
+		for _, spec := range d.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for j, name := range vs.Names {
+				if j < len(vs.Values) {
+					p.Vars[name.Name] = vs.Values[j]
+				}
+			}
+		}
+	case token.CONST:
+		// Within a const block, a spec without a type or values repeats the
+		// type and values of the previous spec
+		var typ ast.Expr
+		var values []ast.Expr
+		for i, spec := range d.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if vs.Type != nil || len(vs.Values) > 0 {
+				typ, values = vs.Type, vs.Values
+			}
+			doc := vs.Doc
+			if doc == nil && len(d.Specs) == 1 {
+				doc = d.Doc
+			}
+			for j, name := range vs.Names {
+				c := &Const{Name: name.Name, Iota: i, Doc: doc, Spec: vs}
+				if ident, ok := typ.(*ast.Ident); ok {
+					c.Type = ident.Name
+				}
+				if j < len(values) {
+					c.Value = values[j]
+				}
+				p.Consts[c.Name] = c
+				p.ConstNames = append(p.ConstNames, c.Name)
+			}
+		}
+	}
+}
+
+// TypeDoc returns the doc comment of a type definition, which for a single
+// definition is the doc comment of its declaration
+func TypeDoc(d *ast.GenDecl, ts *ast.TypeSpec) *ast.CommentGroup {
+	if ts.Doc == nil && len(d.Specs) == 1 {
+		return d.Doc
+	}
+	return ts.Doc
+}
+
+// ConstsOfType returns the constants declared with the named type, in
+// declaration order
+func (p *Package) ConstsOfType(typeName string) []*Const {
+	var consts []*Const
+	for _, name := range p.ConstNames {
+		if c := p.Consts[name]; c.Type == typeName {
+			consts = append(consts, c)
+		}
+	}
+	return consts
+}
+
+// HasMethod reports whether the named type declares a method with the given
+// name, on either a value or a pointer receiver
+func (p *Package) HasMethod(typeName, method string) bool {
+	return p.Method(typeName, method) != nil
+}
+
+// Method returns the declaration of the named type's method, or nil
+func (p *Package) Method(typeName, method string) *ast.FuncDecl {
+	if p == nil {
+		return nil
+	}
+	for _, fn := range p.Methods[typeName] {
+		if fn.Name.Name == method {
+			return fn
+		}
+	}
+	return nil
+}
+
+// ReceiverName returns the name of the receiver type of a method declaration,
+// or the empty string for a function
+func ReceiverName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
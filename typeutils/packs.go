@@ -0,0 +1,109 @@
+package typeutils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Packs are curated mappings of common third-party Go types to the Flow types of
+// their JSON encoding, selectable by name. Types are keyed by import path.
+var Packs = map[string]map[string]string{
+	"k8s": {
+		"k8s.io/apimachinery/pkg/api/resource.Quantity":   "string",
+		"k8s.io/apimachinery/pkg/util/intstr.IntOrString": "number | string",
+		"k8s.io/apimachinery/pkg/types.UID":               "string",
+		"k8s.io/apimachinery/pkg/apis/meta/v1.Duration":   "string",
+		"k8s.io/apimachinery/pkg/apis/meta/v1.Time":       "?string",
+		"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime":  "?string",
+		"k8s.io/apimachinery/pkg/runtime.RawExtension":    "mixed",
+		"k8s.io/apimachinery/pkg/apis/meta/v1.FieldsV1":   "mixed",
+	},
+	"sql": {
+		"database/sql.NullString":  "?string",
+		"database/sql.NullInt64":   "?number",
+		"database/sql.NullInt32":   "?number",
+		"database/sql.NullInt16":   "?number",
+		"database/sql.NullByte":    "?number",
+		"database/sql.NullFloat64": "?number",
+		"database/sql.NullBool":    "?boolean",
+		"database/sql.NullTime":    "?string",
+	},
+	"uuid": {
+		"github.com/google/uuid.UUID":        "string",
+		"github.com/google/uuid.NullUUID":    "?string",
+		"github.com/gofrs/uuid.UUID":         "string",
+		"github.com/gofrs/uuid.NullUUID":     "?string",
+		"github.com/gofrs/uuid/v5.UUID":      "string",
+		"github.com/gofrs/uuid/v5.NullUUID":  "?string",
+		"github.com/satori/go.uuid.UUID":     "string",
+		"github.com/satori/go.uuid.NullUUID": "?string",
+	},
+	"decimal": {
+		"github.com/shopspring/decimal.Decimal":     "string",
+		"github.com/shopspring/decimal.NullDecimal": "?string",
+	},
+	"null": nullPack(),
+	"time": {
+		// Durations are encoded as their number of nanoseconds
+		"time.Duration": "number",
+		"time.Month":    "number",
+		"time.Weekday":  "number",
+	},
+	"url": {
+		// url.URL only implements encoding.BinaryMarshaler, which
+		// encoding/json doesn't use, so it's encoded as a struct
+		"net/url.URL": "{Scheme: string, Opaque: string, User: ?{}, Host: string, Path: string, " +
+			"RawPath: string, OmitHost: boolean, ForceQuery: boolean, RawQuery: string, Fragment: string, RawFragment: string}",
+		"net/url.Userinfo": "{}",
+	},
+}
+
+// nullPack returns the mappings for the types of the guregu/null packages,
+// which are null when invalid, and of their zero subpackages, which are the
+// zero value instead
+func nullPack() map[string]string {
+	pack := map[string]string{}
+	types := map[string]string{
+		"String": "string",
+		"Int":    "number",
+		"Int32":  "number",
+		"Int16":  "number",
+		"Byte":   "number",
+		"Float":  "number",
+		"Bool":   "boolean",
+		"Time":   "string",
+	}
+	for _, pkgPath := range []string{"gopkg.in/guregu/null.v3", "gopkg.in/guregu/null.v4", "github.com/guregu/null/v5"} {
+		for name, flowType := range types {
+			pack[pkgPath+"."+name] = "?" + flowType
+			pack[pkgPath+"/zero."+name] = flowType
+		}
+	}
+	return pack
+}
+
+// PackMappings returns the type mappings of the named packs
+func PackMappings(names []string) (map[string]string, error) {
+	mappings := map[string]string{}
+	for _, name := range names {
+		pack, ok := Packs[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown mapping pack %q, expected one of %s", name, strings.Join(PackNames(), ", "))
+		}
+		for goType, flowType := range pack {
+			mappings[goType] = flowType
+		}
+	}
+	return mappings, nil
+}
+
+// PackNames returns the names of the available packs in alphabetical order
+func PackNames() []string {
+	names := make([]string, 0, len(Packs))
+	for name := range Packs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,161 @@
+package typeutils
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IsStringerFile reports whether the Go source is a file generated by stringer
+func IsStringerFile(src []byte) bool {
+	return strings.Contains(string(src), "Code generated by \"stringer ")
+}
+
+// StringerFiles returns the stringer generated files in dir, e.g. the
+// pill_string.go companion of a file defining the Pill type
+func StringerFiles(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*_string.go"))
+	var files []string
+	for _, match := range matches {
+		src, err := ioutil.ReadFile(match)
+		if err == nil && IsStringerFile(src) {
+			files = append(files, match)
+		}
+	}
+	return files
+}
+
+// StringerNames returns the names that the stringer generated String method of
+// the named type returns for its values, in ascending order of value. The names
+// are read from the `_T_name` constants, sliced by the `_T_index` or `_T_map`
+// tables, so they account for stringer's -trimprefix and -linecomment flags.
+func (p *Package) StringerNames(typeName string) ([]string, bool) {
+	prefix := "_" + typeName + "_"
+	if _, ok := p.Consts[prefix+"name"]; ok {
+		// A single run of values, or a map of sparse values
+		if m, ok := p.Vars[prefix+"map"].(*ast.CompositeLit); ok {
+			return p.stringerMapNames(prefix+"name", m)
+		}
+		return p.stringerRunNames(prefix+"name", prefix+"index")
+	}
+
+	// Multiple runs of values, _T_name_0, _T_name_1...
+	var names []string
+	for i := 0; ; i++ {
+		suffix := "_" + strconv.Itoa(i)
+		if _, ok := p.Consts[prefix+"name"+suffix]; !ok {
+			break
+		}
+		run, ok := p.stringerRunNames(prefix+"name"+suffix, prefix+"index"+suffix)
+		if !ok {
+			return nil, false
+		}
+		names = append(names, run...)
+	}
+	return names, len(names) > 0
+}
+
+// stringerRunNames slices the name constant of a run of values by its index
+// table. A run of a single value has no index table.
+func (p *Package) stringerRunNames(nameConst, indexVar string) ([]string, bool) {
+	value, ok := p.ConstValue(nameConst)
+	if !ok || value.Kind() != constant.String {
+		return nil, false
+	}
+	all := constant.StringVal(value)
+
+	index, ok := p.Vars[indexVar].(*ast.CompositeLit)
+	if !ok {
+		return []string{all}, true
+	}
+	var offsets []int
+	for _, elt := range index.Elts {
+		lit, ok := elt.(*ast.BasicLit)
+		if !ok {
+			return nil, false
+		}
+		offset, err := strconv.Atoi(lit.Value)
+		if err != nil || offset > len(all) {
+			return nil, false
+		}
+		offsets = append(offsets, offset)
+	}
+
+	var names []string
+	for i := 0; i+1 < len(offsets); i++ {
+		names = append(names, all[offsets[i]:offsets[i+1]])
+	}
+	return names, true
+}
+
+// stringerMapNames reads the names of the `_T_map` table of sparse values, whose
+// values are slices of the name constant
+func (p *Package) stringerMapNames(nameConst string, m *ast.CompositeLit) ([]string, bool) {
+	value, ok := p.ConstValue(nameConst)
+	if !ok || value.Kind() != constant.String {
+		return nil, false
+	}
+	all := constant.StringVal(value)
+
+	var names []string
+	for _, elt := range m.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, false
+		}
+		slice, ok := kv.Value.(*ast.SliceExpr)
+		if !ok {
+			return nil, false
+		}
+		low, lowOK := sliceBound(slice.Low, 0)
+		high, highOK := sliceBound(slice.High, len(all))
+		if !lowOK || !highOK || low > high || high > len(all) {
+			return nil, false
+		}
+		names = append(names, all[low:high])
+	}
+	return names, true
+}
+
+func sliceBound(expr ast.Expr, def int) (int, bool) {
+	if expr == nil {
+		return def, true
+	}
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(lit.Value)
+	return n, err == nil
+}
+
+// SortedConstValues returns the distinct values of the constants of the named
+// type in ascending order, along with the constants having each value
+func (p *Package) SortedConstValues(typeName string) ([]constant.Value, map[string][]*Const, error) {
+	var values []constant.Value
+	consts := map[string][]*Const{}
+	for _, c := range p.ConstsOfType(typeName) {
+		if c.Name == "_" {
+			continue
+		}
+		v, ok := p.ConstValue(c.Name)
+		if !ok {
+			return nil, nil, fmt.Errorf("cannot evaluate the value of %s", c.Name)
+		}
+		key := v.ExactString()
+		if _, ok := consts[key]; !ok {
+			values = append(values, v)
+		}
+		consts[key] = append(consts[key], c)
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return constant.Compare(values[i], token.LSS, values[j])
+	})
+	return values, consts, nil
+}
@@ -0,0 +1,90 @@
+package typeutils
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultTagKeys are the struct tag keys consulted when none are configured
+var DefaultTagKeys = []string{"json"}
+
+// TagInfo describes how an encoder names a struct field and whether it may omit
+// it, based on the field's struct tag
+type TagInfo struct {
+	// Key is the struct tag key that decided the field's encoding
+	Key string
+	// Name is the property name, empty when the encoder derives it from the
+	// Go field name
+	Name string
+	// IsOptional is set when the encoder may omit the field (`omitempty`, or
+	// json's `omitzero`)
+	IsOptional bool
+	// Inline is set when the encoder flattens the field's properties into the
+	// enclosing object (yaml and bson `inline`, mapstructure `squash`)
+	Inline bool
+	// Skip is set when the encoder ignores the field (`-`)
+	Skip bool
+	// Options are the tag options following the name
+	Options []string
+}
+
+// HasOption reports whether the tag includes the given option
+func (info TagInfo) HasOption(option string) bool {
+	for _, o := range info.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTag returns the tag info for the first of the keys present in a struct
+// field's tag, which may still be quoted as in the source. ok is false if none
+// of the keys are present.
+func ParseTag(tag string, keys []string) (info TagInfo, ok bool) {
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		tag = unquoted
+	}
+
+	for _, key := range keys {
+		value, present := reflect.StructTag(tag).Lookup(key)
+		if !present {
+			continue
+		}
+
+		s := strings.Split(value, ",")
+		info = TagInfo{Key: key, Name: s[0], Options: s[1:]}
+		// `-` alone skips the field, `-,` names it "-"
+		if value == "-" {
+			info.Skip = true
+			return info, true
+		}
+		info.IsOptional = info.HasOption("omitempty")
+
+		// Options the encoders don't share
+		switch key {
+		case "json":
+			// Go 1.24 omits zero values, or values whose IsZero method
+			// reports true
+			info.IsOptional = info.IsOptional || info.HasOption("omitzero")
+		case "yaml", "bson":
+			info.Inline = info.HasOption("inline")
+		case "mapstructure":
+			// `remain` collects the remaining keys, so it's inlined as well
+			info.Inline = info.HasOption("squash") || info.HasOption("remain")
+		}
+		return info, true
+	}
+	return TagInfo{}, false
+}
+
+// DefaultFieldName returns the property name an encoder derives from the Go
+// field name when its tag doesn't name the field
+func DefaultFieldName(key, fieldName string) string {
+	switch key {
+	case "yaml", "bson":
+		return strings.ToLower(fieldName)
+	}
+	return fieldName
+}
@@ -3,18 +3,181 @@ package typeutils
 import (
 	"fmt"
 	"go/ast"
-	"regexp"
-	"strings"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"github.com/kristiehoward/go2flow/model"
 )
 
-// Map the string representation of each reflect.Type to the Flow type for that
-// primitive once it is sent as a JSON object.
+// Map the string representation of each reflect.Type to the type for that
+// primitive once it is sent as a JSON object
 var goTypeToFlowType = map[string]string{
-	"bool":      "boolean",
-	"int":       "number",
-	"int64":     "number",
-	"string":    "string",
-	"time.Time": "string",
+	"bool":      model.Boolean,
+	"int":       model.Number,
+	"int8":      model.Number,
+	"int16":     model.Number,
+	"int32":     model.Number,
+	"int64":     model.Number,
+	"uint":      model.Number,
+	"uint8":     model.Number,
+	"uint16":    model.Number,
+	"uint32":    model.Number,
+	"uint64":    model.Number,
+	"uintptr":   model.Number,
+	"byte":      model.Number,
+	"rune":      model.Number,
+	"float32":   model.Number,
+	"float64":   model.Number,
+	"string":    model.String,
+	"time.Time": model.String,
+	"any":       model.Unknown,
+}
+
+// Translator converts Go type expressions into the type expressions of the
+// model using its TypeMap
+type Translator struct {
+	// TypeMap maps the string representation of a Go type to its type
+	TypeMap map[string]*model.TypeExpr
+	// Fset resolves the positions of reported diagnostics
+	Fset *token.FileSet
+	// Pkg indexes the declarations of the package being translated
+	Pkg *Package
+	// Imports maps the package names used in the file being translated to
+	// their import paths
+	Imports map[string]string
+	// ResolveImport reports whether Flow definitions are available for the
+	// types of the Go package with the given import path
+	ResolveImport func(pkgPath string) bool
+	// imported records the imported types referenced by the file
+	imported []ImportedType
+	// Diagnostics records the type expressions that could not be translated
+	Diagnostics []Diagnostic
+	// MaxTupleLength is the longest fixed-size array translated to a tuple,
+	// longer arrays are translated to Array<T>
+	MaxTupleLength int
+}
+
+// DefaultMaxTupleLength is the longest fixed-size array translated to a tuple
+// unless configured otherwise
+const DefaultMaxTupleLength = 8
+
+// NewTranslator returns a Translator that uses the default Go type mappings,
+// overridden and extended by mappings
+func NewTranslator(mappings map[string]*model.TypeExpr) *Translator {
+	typeMap := make(map[string]*model.TypeExpr, len(goTypeToFlowType)+len(mappings))
+	for goType, primitive := range goTypeToFlowType {
+		typeMap[goType] = model.Primitive(primitive)
+	}
+	for goType, flowType := range mappings {
+		typeMap[goType] = flowType
+	}
+	return &Translator{TypeMap: typeMap, MaxTupleLength: DefaultMaxTupleLength}
+}
+
+// Underlying returns the type expression that the encoding of a type defined
+// from expr follows, resolving the names of the package's type definitions to
+// their own type expressions, e.g. `bool` for BoolAlias in
+//
+//	type BoolAlias bool
+//	type AliasType BoolAlias
+func (tr *Translator) Underlying(expr ast.Expr) ast.Expr {
+	seen := map[string]bool{}
+	for tr.Pkg != nil {
+		ident, ok := expr.(*ast.Ident)
+		if !ok || seen[ident.Name] {
+			break
+		}
+		ts, ok := tr.Pkg.Types[ident.Name]
+		if !ok {
+			break
+		}
+		seen[ident.Name] = true
+		expr = ts.Type
+	}
+	return expr
+}
+
+// structTypes are imported types known to be structs, which encoding/json never
+// considers empty
+var structTypes = map[string]bool{
+	"time.Time":                true,
+	"net/url.URL":              true,
+	"database/sql.NullString":  true,
+	"database/sql.NullInt64":   true,
+	"database/sql.NullInt32":   true,
+	"database/sql.NullInt16":   true,
+	"database/sql.NullByte":    true,
+	"database/sql.NullFloat64": true,
+	"database/sql.NullBool":    true,
+	"database/sql.NullTime":    true,
+
+	"k8s.io/apimachinery/pkg/api/resource.Quantity":   true,
+	"k8s.io/apimachinery/pkg/util/intstr.IntOrString": true,
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Time":       true,
+	"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime":  true,
+	"k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta": true,
+	"k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta":   true,
+	"k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta":   true,
+	"github.com/shopspring/decimal.Decimal":           true,
+}
+
+// OmitsEmpty reports whether encoding/json's `omitempty` can omit a value of
+// the type: false, 0, nil pointers and interfaces, and empty strings, slices and
+// maps are omitted, but structs and non-empty arrays never are
+func (tr *Translator) OmitsEmpty(expr ast.Expr) bool {
+	switch t := tr.Underlying(expr).(type) {
+	case *ast.StructType:
+		return false
+	case *ast.ArrayType:
+		// [N]T is only empty when N is 0
+		return t.Len == nil
+	case *ast.SelectorExpr:
+		typeStr := fmt.Sprintf("%s.%s", t.X, t.Sel)
+		if pkgPath, ok := tr.Imports[fmt.Sprint(t.X)]; ok {
+			typeStr = pkgPath + "." + t.Sel.Name
+		}
+		return !structTypes[typeStr]
+	}
+	return true
+}
+
+// ArrayOf returns the type of a slice or array type whose elements have the
+// given type
+func (tr *Translator) ArrayOf(t *ast.ArrayType, elementType *model.TypeExpr) *model.TypeExpr {
+	if t.Len != nil {
+		// [N]T is encoded as an array of exactly N values
+		if n, ok := tr.arrayLen(t); ok && n <= tr.MaxTupleLength {
+			elements := make([]*model.TypeExpr, n)
+			for i := range elements {
+				elements[i] = elementType
+			}
+			return model.TupleOf(elements...)
+		}
+	}
+	return model.ArrayOf(elementType)
+}
+
+// IsByteSlice reports whether the array type is a []byte
+func IsByteSlice(t *ast.ArrayType) bool {
+	elt, ok := t.Elt.(*ast.Ident)
+	return ok && t.Len == nil && (elt.Name == "byte" || elt.Name == "uint8")
+}
+
+// arrayLen evaluates the length of a fixed-size array type, which may be a
+// constant expression of the package's constants. ok is false if it depends on
+// constants of other packages.
+func (tr *Translator) arrayLen(t *ast.ArrayType) (n int, ok bool) {
+	pkg := tr.Pkg
+	if pkg == nil {
+		pkg = &Package{}
+	}
+	v, ok := pkg.eval(t.Len, 0, map[string]bool{})
+	if !ok || v.Kind() != constant.Int {
+		return 0, false
+	}
+	length, ok := constant.Int64Val(v)
+	return int(length), ok && length >= 0
 }
 
 // IsNullable Given a field, return if it is nullable. A field is nullable if it is a pointer.
@@ -26,80 +189,87 @@ func IsNullable(f ast.Field) bool {
 }
 
 // GetTagInfo Returns the name of the JSON field and whether or not the field is
-// optional based on a struct field's tag
-// TODO Kristie 10/24/17 - Update to include the edge cases in
-// https://golang.org/pkg/encoding/json/#Marshal
+// optional based on a struct field's tag. Fields without a JSON name are
+// reported without a name, and fields with a `required` validation rule are
+// never optional.
 func GetTagInfo(tag string) (name string, isOptional bool) {
-	name = ""
-	isOptional = false
-
-	// Only parse tags in the form `json:"FIELDNAME,omitempty"` where `,omitempty`
-	// is optional
-	if !strings.Contains(tag, `json:"`) {
-		return
-	}
-	// This regex matches a json tag only, omitting the `,omitempty` if present
-	re := regexp.MustCompile(`json:"([^,"]+)"?`)
-	matches := re.FindStringSubmatch(tag)
-
-	// Capture group match will be the 2nd element
-	if len(matches) < 2 {
-		return
+	info, ok := ParseTag(tag, []string{"json"})
+	if !ok || info.Skip || info.Name == "" {
+		return "", false
 	}
-
-	name = matches[1]
-	isOptional = strings.Contains(tag, ",omitempty")
-	return
+	return info.Name, info.IsOptional && !ParseValidation(tag).Required
 }
 
-// GetTypeInfo returns a string representing the Flow type for a given fieldType
-// that's an ast.Expr
+// TypeOf returns the type of a Go type expression
 // TODO Kristie 10/24/17
 // - Add tests
 // - Specifically test the recursion, nullable, and optional types
 // - Better Map --> Object handling
-// - Figure out how to handle imported packages and their definitions in Flow
-// - Handle embedded types
 // - Option to keep comments?
 // - Handle unexported fields
-func GetTypeInfo(fieldType ast.Expr) string {
+func TypeOf(fieldType ast.Expr) *model.TypeExpr {
+	return NewTranslator(nil).TypeOf(fieldType)
+}
+
+// TypeOf returns the type of a Go type expression using the translator's type
+// mappings
+func (tr *Translator) TypeOf(fieldType ast.Expr) *model.TypeExpr {
 	switch t := fieldType.(type) {
 	// *T
 	case *ast.StarExpr:
 		// Return the type of T, assume that the meaning of the pointer was
 		// handled in the calling function
-		return GetTypeInfo(t.X)
+		return tr.TypeOf(t.X)
 	// []T
 	case *ast.ArrayType:
-		elementType := GetTypeInfo(t.Elt)
-		return fmt.Sprintf("Array<%s>", elementType)
+		if IsByteSlice(t) {
+			// []byte is encoded as a base64 string
+			return model.Primitive(model.String)
+		}
+		return tr.ArrayOf(t, tr.TypeOf(t.Elt))
 	// map[T1]T2
 	case *ast.MapType:
-		keyType := GetTypeInfo(t.Key)
-		valueType := GetTypeInfo(t.Value)
-		return fmt.Sprintf("{[%s]: %s}", keyType, valueType)
+		return model.MapOf(tr.TypeOf(t.Key), tr.TypeOf(t.Value))
 	// Imported type package.T
 	case *ast.SelectorExpr:
-		typeStr := fmt.Sprintf("%s.%s", t.X, t.Sel)
-		flowType, ok := goTypeToFlowType[typeStr]
-		if !ok {
-			// TODO What to do here when we don't recognize this package?
-			return typeStr
+		pkgName := fmt.Sprint(t.X)
+		typeStr := fmt.Sprintf("%s.%s", pkgName, t.Sel)
+		if mapped, ok := tr.TypeMap[typeStr]; ok {
+			return mapped.Clone()
 		}
-		return flowType
+		// Mappings may also use the package's import path, and the
+		// definitions of the package may be imported
+		if pkgPath, ok := tr.Imports[pkgName]; ok {
+			if mapped, ok := tr.TypeMap[pkgPath+"."+t.Sel.Name]; ok {
+				return mapped.Clone()
+			}
+			if tr.ResolveImport != nil && tr.ResolveImport(pkgPath) {
+				return model.Ref(tr.importType(pkgPath, pkgName, t.Sel.Name))
+			}
+		}
+		tr.Report(t, "no Flow type for imported type %s", typeStr)
+		return model.Ref(typeStr)
 	// T
 	case *ast.Ident:
 		// Primitives will exist in the map
-		flowType, ok := goTypeToFlowType[t.Name]
-		// Custom type definitions in this package will have a non-nil t.Obj
-		isCustomType := t.Obj != nil
+		mapped, ok := tr.TypeMap[t.Name]
+		// Custom type definitions in this file will have a non-nil t.Obj, the
+		// ones in other files of this package are in the package index
+		isCustomType := t.Obj != nil || tr.Pkg != nil && tr.Pkg.Types[t.Name] != nil
 		if ok {
-			return flowType
+			return mapped.Clone()
 		} else if isCustomType {
-			return t.Name
+			return model.Ref(t.Name)
 		} else {
-			return "MISSING_TYPE_DEF_IN_MAP"
+			tr.Report(t, "no Flow type for %s", t.Name)
+			return model.Ref("MISSING_TYPE_DEF_IN_MAP")
+		}
+	// interface{}, which accepts any value like any
+	case *ast.InterfaceType:
+		if t.Methods == nil || len(t.Methods.List) == 0 {
+			return tr.TypeMap["any"].Clone()
 		}
 	}
-	return "UNKNOWN_EXPR_TYPE"
+	tr.Report(fieldType, "unsupported type %s", types.ExprString(fieldType))
+	return model.Ref("UNKNOWN_EXPR_TYPE")
 }
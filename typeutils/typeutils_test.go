@@ -1,9 +1,10 @@
 package typeutils
 
 import (
+	"go/ast"
 	"testing"
 
-	"github.com/kristiehoward/go2flow/utils"
+	"github.com/kristiehoward/go2flow/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -66,6 +67,18 @@ func TestGetTagInfo(t *testing.T) {
 			true,
 			"json tag second with additional defns",
 		},
+		{
+			`json:"name,omitempty" validate:"required,max=64"`,
+			"name",
+			false,
+			"Required validation rule",
+		},
+		{
+			`json:"name,omitempty" binding:"omitempty,max=64"`,
+			"name",
+			true,
+			"Optional validation rule",
+		},
 	}
 
 	var name string
@@ -73,7 +86,7 @@ func TestGetTagInfo(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Description, func(t *testing.T) {
-			name, isOptional = utils.GetTagInfo(tc.Tag)
+			name, isOptional = GetTagInfo(tc.Tag)
 			assert.Equal(t, tc.Name, name)
 			if tc.IsOptional {
 				assert.True(t, isOptional)
@@ -84,3 +97,163 @@ func TestGetTagInfo(t *testing.T) {
 	}
 
 }
+
+func TestParseTag(t *testing.T) {
+	type testCase struct {
+		Tag         string
+		Keys        []string
+		Info        TagInfo
+		Ok          bool
+		Description string
+	}
+
+	testCases := []testCase{
+		{
+			"`yaml:\"region,omitempty\" json:\"zone\"`",
+			[]string{"yaml", "json"},
+			TagInfo{Key: "yaml", Name: "region", IsOptional: true, Options: []string{"omitempty"}},
+			true,
+			"Quoted tag, first key wins",
+		},
+		{
+			`json:"zone"`,
+			[]string{"yaml", "json"},
+			TagInfo{Key: "json", Name: "zone", Options: []string{}},
+			true,
+			"Falls back to the next key",
+		},
+		{
+			`yaml:",inline"`,
+			[]string{"yaml"},
+			TagInfo{Key: "yaml", Inline: true, Options: []string{"inline"}},
+			true,
+			"yaml inline",
+		},
+		{
+			`bson:"count,minsize"`,
+			[]string{"bson"},
+			TagInfo{Key: "bson", Name: "count", Options: []string{"minsize"}},
+			true,
+			"bson minsize keeps the field required",
+		},
+		{
+			`mapstructure:",squash"`,
+			[]string{"mapstructure"},
+			TagInfo{Key: "mapstructure", Inline: true, Options: []string{"squash"}},
+			true,
+			"mapstructure squash",
+		},
+		{
+			`json:"inline"`,
+			[]string{"json"},
+			TagInfo{Key: "json", Name: "inline", Options: []string{}},
+			true,
+			"json has no inline option",
+		},
+		{
+			`json:"-"`,
+			[]string{"json"},
+			TagInfo{Key: "json", Name: "-", Skip: true, Options: []string{}},
+			true,
+			"Skipped field",
+		},
+		{
+			`protobuf:"bytes,1,opt,name=name"`,
+			[]string{"json"},
+			TagInfo{},
+			false,
+			"Missing key",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			info, ok := ParseTag(tc.Tag, tc.Keys)
+			assert.Equal(t, tc.Ok, ok)
+			assert.Equal(t, tc.Info, info)
+		})
+	}
+}
+
+func TestParseValidation(t *testing.T) {
+	type testCase struct {
+		Tag         string
+		Validation  Validation
+		Description string
+	}
+
+	testCases := []testCase{
+		{
+			"`json:\"name\" validate:\"required,min=1,max=64\"`",
+			Validation{Required: true, Bounds: map[string]float64{"min": 1, "max": 64}},
+			"Quoted tag with bounds",
+		},
+		{
+			`binding:"required,oneof=a b c"`,
+			Validation{Required: true, OneOf: []string{"a", "b", "c"}},
+			"gin binding tag",
+		},
+		{
+			`validate:"oneof='red green' 'blue'"`,
+			Validation{OneOf: []string{"red green", "blue"}},
+			"Quoted oneof values",
+		},
+		{
+			`validate:"max=5,dive,required,max=10"`,
+			Validation{Bounds: map[string]float64{"max": 5}},
+			"Rules after dive apply to the elements",
+		},
+		{
+			`validate:"required|len=0,max=1h"`,
+			Validation{},
+			"Alternatives and non-numeric bounds",
+		},
+		{
+			`json:"name"`,
+			Validation{},
+			"No validation tag",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			assert.Equal(t, tc.Validation, ParseValidation(tc.Tag))
+		})
+	}
+}
+
+func TestTypeOfPrimitives(t *testing.T) {
+	for _, name := range []string{"int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune", "float32", "float64"} {
+		t.Run(name, func(t *testing.T) {
+			tr := NewTranslator(nil)
+			assert.Equal(t, model.Primitive(model.Number), tr.TypeOf(ast.NewIdent(name)))
+			assert.Empty(t, tr.Diagnostics)
+		})
+	}
+
+	tr := NewTranslator(nil)
+	assert.Equal(t, model.Primitive(model.Unknown), tr.TypeOf(ast.NewIdent("any")))
+	assert.Equal(t, model.Primitive(model.Unknown), tr.TypeOf(&ast.InterfaceType{Methods: &ast.FieldList{}}))
+	assert.Empty(t, tr.Diagnostics)
+}
+
+func TestBoundKind(t *testing.T) {
+	tr := NewTranslator(nil)
+	for expr, kind := range map[ast.Expr]BoundKind{
+		ast.NewIdent("uint64"):                                                BoundNumber,
+		ast.NewIdent("float32"):                                               BoundNumber,
+		&ast.StarExpr{X: ast.NewIdent("string")}:                              BoundString,
+		&ast.ArrayType{Elt: ast.NewIdent("string")}:                           BoundArray,
+		&ast.ArrayType{Elt: ast.NewIdent("byte")}:                             BoundNone,
+		&ast.MapType{Key: ast.NewIdent("string"), Value: ast.NewIdent("int")}: BoundObject,
+		ast.NewIdent("bool"):                                                  BoundNone,
+	} {
+		assert.Equal(t, kind, tr.BoundKind(expr))
+	}
+
+	v := ParseValidation(`validate:"gt=0,lt=10"`)
+	one, nine, zero, ten := 1, 9, 0.0, 10.0
+	assert.Equal(t, &model.Constraints{MinLength: &one, MaxLength: &nine}, v.Constraints(BoundString))
+	assert.Equal(t, &model.Constraints{ExclusiveMinimum: &zero, ExclusiveMaximum: &ten}, v.Constraints(BoundNumber))
+	assert.Nil(t, v.Constraints(BoundNone))
+}
@@ -0,0 +1,251 @@
+package typeutils
+
+import (
+	"encoding/json"
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/kristiehoward/go2flow/model"
+)
+
+// ValidationTagKeys are the struct tag keys of the validation rules of request
+// structs: go-playground/validator's `validate` and gin's `binding`
+var ValidationTagKeys = []string{"validate", "binding"}
+
+// Validation is the validation rules of a struct field that change its type or
+// constrain its values
+type Validation struct {
+	// Required is set by `required`: the field must be present and non-zero
+	Required bool
+	// OneOf are the values allowed by `oneof=a b c`
+	OneOf []string
+	// Bounds are the `min`, `max`, `len`, `gt`, `gte`, `lt` and `lte` rules
+	// by name, which bound the value of a number and the length of a string,
+	// slice or map
+	Bounds map[string]float64
+}
+
+// BoundRules are the rules that Validation.Bounds collects, in the order
+// they're applied
+var BoundRules = []string{"len", "min", "gte", "gt", "max", "lte", "lt"}
+
+func isBoundRule(name string) bool {
+	for _, rule := range BoundRules {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseValidation returns the validation rules of a struct field's tag, which
+// may still be quoted as in the source, merged across the validation tag keys.
+// The rules following `dive` apply to the elements of a slice or map, and
+// rules with `|` alternatives to either of them, so both are ignored.
+func ParseValidation(tag string) Validation {
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		tag = unquoted
+	}
+
+	v := Validation{}
+	for _, key := range ValidationTagKeys {
+		value, ok := reflect.StructTag(tag).Lookup(key)
+		if !ok {
+			continue
+		}
+		for _, rule := range splitRules(value) {
+			if rule == "dive" {
+				break
+			}
+			if strings.Contains(rule, "|") {
+				continue
+			}
+			s := strings.SplitN(rule, "=", 2)
+			name, param := s[0], ""
+			if len(s) == 2 {
+				param = s[1]
+			}
+			switch {
+			case name == "required":
+				v.Required = true
+			case name == "oneof" && param != "":
+				v.OneOf = splitOneOf(param)
+			case isBoundRule(name):
+				if n, err := strconv.ParseFloat(param, 64); err == nil {
+					if v.Bounds == nil {
+						v.Bounds = map[string]float64{}
+					}
+					v.Bounds[name] = n
+				}
+			}
+		}
+	}
+	return v
+}
+
+// splitRules splits the rules of a validation tag at the commas, except the
+// escaped `0x2C` ones of a parameter
+func splitRules(value string) []string {
+	rules := strings.Split(value, ",")
+	for i, rule := range rules {
+		rules[i] = strings.Replace(strings.TrimSpace(rule), "0x2C", ",", -1)
+	}
+	return rules
+}
+
+// splitOneOf splits the values of a `oneof` rule at the spaces, except the ones
+// within single quotes
+func splitOneOf(param string) []string {
+	var values []string
+	var value strings.Builder
+	quoted, started := false, false
+	for _, r := range param {
+		switch {
+		case r == '\'':
+			quoted, started = !quoted, true
+		case r == ' ' && !quoted:
+			if started {
+				values = append(values, value.String())
+			}
+			value.Reset()
+			started = false
+		default:
+			value.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		values = append(values, value.String())
+	}
+	return values
+}
+
+// OneOfType returns the literal union of the `oneof` values. Values are string
+// literals unless valueType is number.
+func (v Validation) OneOfType(valueType *model.TypeExpr) (*model.TypeExpr, bool) {
+	if len(v.OneOf) == 0 {
+		return nil, false
+	}
+	literals := make([]*model.TypeExpr, len(v.OneOf))
+	for i, value := range v.OneOf {
+		if !valueType.IsPrimitive(model.Number) {
+			literals[i] = model.Literal(value)
+			continue
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, false
+		}
+		literals[i] = model.Literal(json.Number(value))
+	}
+	return model.UnionOf(literals...), true
+}
+
+// BoundKind is what the bounds of a field's validation rules apply to
+type BoundKind string
+
+// Kinds of values that validation rules bound
+const (
+	// BoundNone is a value that isn't bounded
+	BoundNone BoundKind = ""
+	// BoundNumber is the value of a number
+	BoundNumber BoundKind = "number"
+	// BoundString is the length of a string
+	BoundString BoundKind = "string"
+	// BoundArray is the length of a slice or an array
+	BoundArray BoundKind = "array"
+	// BoundObject is the length of a map
+	BoundObject BoundKind = "object"
+)
+
+// BoundKind returns what the validator bounds for a field of the Go type, which
+// follows the type's translation: the value of a number, or the length of a
+// string, a slice or a map. It's BoundNone for the other types, and for []byte,
+// which is validated by its length in bytes but encoded as a base64 string.
+func (tr *Translator) BoundKind(expr ast.Expr) BoundKind {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := tr.Underlying(expr).(type) {
+	case *ast.Ident:
+		switch goTypeToFlowType[t.Name] {
+		case model.Number:
+			return BoundNumber
+		case model.String:
+			return BoundString
+		}
+	case *ast.ArrayType:
+		if !IsByteSlice(t) {
+			return BoundArray
+		}
+	case *ast.MapType:
+		return BoundObject
+	}
+	return BoundNone
+}
+
+// Constraints returns the bounds of the rules for a value of the kind, nil if
+// they set none
+func (v Validation) Constraints(kind BoundKind) *model.Constraints {
+	if len(v.Bounds) == 0 {
+		return nil
+	}
+	c := &model.Constraints{}
+	if kind == BoundNumber {
+		for _, rule := range BoundRules {
+			n, ok := v.Bounds[rule]
+			if !ok {
+				continue
+			}
+			switch rule {
+			case "min", "gte":
+				c.Minimum = &n
+			case "max", "lte":
+				c.Maximum = &n
+			case "len":
+				c.Minimum, c.Maximum = &n, &n
+			case "gt":
+				c.ExclusiveMinimum = &n
+			case "lt":
+				c.ExclusiveMaximum = &n
+			}
+		}
+		return c
+	}
+
+	var min, max **int
+	switch kind {
+	case BoundString:
+		min, max = &c.MinLength, &c.MaxLength
+	case BoundArray:
+		min, max = &c.MinItems, &c.MaxItems
+	case BoundObject:
+		min, max = &c.MinProperties, &c.MaxProperties
+	default:
+		return nil
+	}
+	for _, rule := range BoundRules {
+		n, ok := v.Bounds[rule]
+		if !ok {
+			continue
+		}
+		// Lengths are integers, so the exclusive bounds are the next ones
+		length := int(n)
+		switch rule {
+		case "min", "gte":
+			*min = &length
+		case "max", "lte":
+			*max = &length
+		case "len":
+			*min, *max = &length, &length
+		case "gt":
+			length++
+			*min = &length
+		case "lt":
+			length--
+			*max = &length
+		}
+	}
+	return c
+}